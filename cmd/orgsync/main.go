@@ -0,0 +1,94 @@
+// Command orgsync reconciles teams and users with an org chart file. It
+// prints the planned changes and applies them only with -apply.
+//
+//	orgsync -file org.yaml          # show the diff
+//	orgsync -file org.csv -apply    # apply it
+//
+// It connects with the same DB_* variables as the server and, like the
+// server, assigns reviewers according to POLICY_FILE when it is set.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"pr-reviewer/internal/notify"
+	"pr-reviewer/internal/orgchart"
+	"pr-reviewer/internal/policyfile"
+	"pr-reviewer/internal/store"
+)
+
+func main() {
+	file := flag.String("file", "", "org chart file (.csv, .yaml or .yml)")
+	format := flag.String("format", "", "csv or yaml; guessed from the file name if empty")
+	apply := flag.Bool("apply", false, "apply the changes instead of only printing them")
+	flag.Parse()
+
+	if *file == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *format == "" {
+		f, err := orgchart.FormatOf(*file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		*format = f
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		log.Fatal(err)
+	}
+	chart, err := orgchart.Parse(f, *format)
+	f.Close()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	dsn := fmt.Sprintf("postgres://%s:%s@%s:%s/%s",
+		os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), os.Getenv("DB_HOST"), os.Getenv("DB_PORT"), os.Getenv("DB_NAME"))
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		log.Fatalf("Could not connect to database: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	st := store.New(db)
+
+	if path := os.Getenv("POLICY_FILE"); path != "" {
+		pf, err := policyfile.Load(path)
+		if err == nil {
+			err = st.ApplyPolicyFile(ctx, pf)
+		}
+		if err != nil {
+			log.Fatalf("Invalid policy file: %v", err)
+		}
+	}
+
+	result, err := st.SyncOrgChart(ctx, chart, *apply)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if result.Deactivation != nil {
+		var notifier notify.Notifier = notify.LogNotifier{}
+		if url := os.Getenv("NOTIFY_WEBHOOK_URL"); url != "" {
+			notifier = notify.NewHTTPNotifier(url)
+		}
+		notify.AlertLeads(ctx, notifier, result.Deactivation.LeadAlerts)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(result); err != nil {
+		log.Fatal(err)
+	}
+}
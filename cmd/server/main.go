@@ -1,19 +1,34 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
 	"time"
 
 	_ "github.com/jackc/pgx/v5/stdlib" 
 	"pr-reviewer/internal/api"
+	"pr-reviewer/internal/notify"
+	"pr-reviewer/internal/policyfile"
 	"pr-reviewer/internal/store"
+	"pr-reviewer/internal/worker"
 )
 
 func main() {
+	// SIGHUP reloads the policy file. It is caught from the start, so a
+	// reload sent while the server is still starting does not kill it.
+	hup := make(chan os.Signal, 1)
+	if os.Getenv("POLICY_FILE") != "" {
+		signal.Notify(hup, syscall.SIGHUP)
+	}
+
 	dbHost := os.Getenv("DB_HOST")
 	dbPort := os.Getenv("DB_PORT")
 	dbUser := os.Getenv("DB_USER")
@@ -43,8 +58,39 @@ func main() {
 	
 	log.Println("Connected to database")
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	st := store.New(db)
-	h := api.NewHandler(st)
+
+	if path := os.Getenv("POLICY_FILE"); path != "" {
+		pf, err := policyfile.Load(path)
+		if err == nil {
+			err = st.ApplyPolicyFile(ctx, pf)
+		}
+		if err != nil {
+			log.Fatalf("Invalid policy file: %v", err)
+		}
+		log.Printf("Loaded policy file %s (%d team override(s))", path, len(pf.Teams))
+		go policyfile.NewWatcher(st, path, envDuration("POLICY_RELOAD_INTERVAL", 10*time.Second), hup).Run(ctx)
+	}
+
+	var notifier notify.Notifier = notify.LogNotifier{}
+	if url := os.Getenv("NOTIFY_WEBHOOK_URL"); url != "" {
+		notifier = notify.NewHTTPNotifier(url)
+	}
+	h := api.NewHandler(st, notifier)
+
+	go worker.NewSLAWorker(st, envDuration("SLA_CHECK_INTERVAL", time.Minute)).Run(ctx)
+	go worker.NewReminderWorker(st, notifier, envDuration("REMINDER_CHECK_INTERVAL", time.Minute)).Run(ctx)
+	go worker.NewJobWorker(st, notifier, envDuration("JOB_POLL_INTERVAL", 2*time.Second)).Run(ctx)
+	go worker.NewOffboardingWorker(st, notifier, envDuration("OFFBOARDING_CHECK_INTERVAL", time.Minute)).Run(ctx)
+	go worker.NewDelegationWorker(st, envDuration("DELEGATION_CHECK_INTERVAL", time.Minute)).Run(ctx)
+
+	if days, err := strconv.Atoi(os.Getenv("RETENTION_DAYS")); err == nil && days > 0 {
+		retention := time.Duration(days) * 24 * time.Hour
+		go worker.NewArchiveWorker(st, retention, envDuration("ARCHIVE_INTERVAL", 24*time.Hour)).Run(ctx)
+	}
 
 	mux := http.NewServeMux()
 
@@ -55,17 +101,52 @@ func main() {
 
 	mux.HandleFunc("POST /team/add", h.CreateTeam)
 	mux.HandleFunc("GET /team/get", h.GetTeam)
+	mux.HandleFunc("GET /team/list", h.ListTeams)
+	mux.HandleFunc("POST /team/addMembers", h.AddTeamMembers)
+	mux.HandleFunc("POST /team/removeMembers", h.RemoveTeamMembers)
+	mux.HandleFunc("POST /team/setMemberActive", h.SetMemberActive)
+	mux.HandleFunc("POST /team/setParent", h.SetTeamParent)
+	mux.HandleFunc("POST /team/setLeads", h.SetTeamLeads)
+	mux.HandleFunc("POST /team/delete", h.RemoveTeam)
 	mux.HandleFunc("POST /team/bulkDeactivate", h.BulkDeactivate)
+	mux.HandleFunc("POST /team/bulkReactivate", h.BulkReactivate)
+	mux.HandleFunc("POST /team/rebalance", h.RebalanceTeam)
+	mux.HandleFunc("GET /team/policy", h.GetTeamPolicy)
+	mux.HandleFunc("POST /team/setPolicy", h.SetTeamPolicy)
 
 	mux.HandleFunc("POST /users/setIsActive", h.SetUserActive)
 	mux.HandleFunc("GET /users/getReview", h.GetUserReviews)
+	mux.HandleFunc("POST /users/transfer", h.TransferUser)
+	mux.HandleFunc("POST /users/handoffReviews", h.HandoffReviews)
+	mux.HandleFunc("GET /users/get", h.GetUser)
+	mux.HandleFunc("POST /users/update", h.UpdateUser)
+	mux.HandleFunc("POST /users/delete", h.DeleteUser)
+	mux.HandleFunc("POST /users/scheduleDeactivation", h.ScheduleDeactivation)
+	mux.HandleFunc("GET /users/scheduledDeactivations", h.ListScheduledDeactivations)
+	mux.HandleFunc("POST /users/cancelScheduledDeactivation", h.CancelScheduledDeactivation)
+	mux.HandleFunc("POST /users/delegate", h.CreateDelegation)
+	mux.HandleFunc("GET /users/delegations", h.ListDelegations)
+	mux.HandleFunc("POST /users/cancelDelegation", h.CancelDelegation)
 
 	mux.HandleFunc("POST /pullRequest/create", h.CreatePullRequest)
+	mux.HandleFunc("POST /pullRequest/createBatch", h.CreatePullRequestBatch)
+	mux.HandleFunc("GET /pullRequest/get", h.GetPullRequest)
 	mux.HandleFunc("POST /pullRequest/merge", h.MergePullRequest)
 	mux.HandleFunc("POST /pullRequest/reassign", h.ReassignReviewer)
 
+	mux.HandleFunc("POST /jobs/bulkDeactivate", h.SubmitBulkDeactivation)
+	mux.HandleFunc("POST /jobs/bulkReactivate", h.SubmitBulkReactivation)
+	mux.HandleFunc("GET /jobs/get", h.GetJob)
+	mux.HandleFunc("POST /jobs/cancel", h.CancelJob)
+
 	mux.HandleFunc("GET /stats", h.GetStats)
 
+	mux.HandleFunc("POST /admin/archive", h.ArchivePullRequests)
+	mux.HandleFunc("GET /admin/export", h.ExportSnapshot)
+	mux.HandleFunc("POST /admin/import", h.ImportSnapshot)
+	mux.HandleFunc("POST /admin/orgSync", h.SyncOrgChart)
+	mux.HandleFunc("GET /admin/policyFile", h.GetPolicyFile)
+
 	srv := &http.Server{
 		Addr:         ":8080",
 		Handler:      mux,
@@ -73,8 +154,28 @@ func main() {
 		WriteTimeout: 10 * time.Second,
 	}
 
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
 	log.Println("Server starting on :8080")
-	if err := srv.ListenAndServe(); err != nil {
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatalf("Server failed: %v", err)
 	}
-}
\ No newline at end of file
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Printf("Invalid %s=%q, using %s", key, v, def)
+		return def
+	}
+	return d
+}
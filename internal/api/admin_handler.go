@@ -0,0 +1,138 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"pr-reviewer/internal/orgchart"
+	"pr-reviewer/internal/store"
+)
+
+func (h *Handler) ArchivePullRequests(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		OlderThanDays int `json:"older_than_days"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid request body")
+		return
+	}
+
+	if req.OlderThanDays <= 0 {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "older_than_days must be positive")
+		return
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -req.OlderThanDays)
+	result, err := h.store.ArchivePullRequests(r.Context(), cutoff)
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, result)
+}
+
+func (h *Handler) ExportSnapshot(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "ndjson" {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "format must be json or ndjson")
+		return
+	}
+
+	snap, err := h.store.ExportSnapshot(r.Context())
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	if format != "ndjson" {
+		h.respondJSON(w, http.StatusOK, snap)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	if err := snap.WriteNDJSON(w); err != nil {
+		log.Printf("writing snapshot failed: %v", err)
+	}
+}
+
+func (h *Handler) ImportSnapshot(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	var snap *store.Snapshot
+	switch format {
+	case "", "json":
+		snap = &store.Snapshot{}
+		if err := json.NewDecoder(r.Body).Decode(snap); err != nil {
+			h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid request body")
+			return
+		}
+	case "ndjson":
+		var err error
+		if snap, err = store.ReadSnapshotNDJSON(r.Body); err != nil {
+			if errors.Is(err, store.ErrInvalidSnapshot) {
+				h.respondError(w, http.StatusBadRequest, "INVALID_SNAPSHOT", err.Error())
+				return
+			}
+			h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid request body")
+			return
+		}
+	default:
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "format must be json or ndjson")
+		return
+	}
+
+	result, err := h.store.ImportSnapshot(r.Context(), snap)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrInvalidSnapshot):
+			h.respondError(w, http.StatusBadRequest, "INVALID_SNAPSHOT", err.Error())
+		case errors.Is(err, store.ErrNotEmpty):
+			h.respondError(w, http.StatusConflict, "NOT_EMPTY", "import requires an empty database")
+		default:
+			h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, result)
+}
+
+func (h *Handler) SyncOrgChart(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format != orgchart.FormatCSV && format != orgchart.FormatYAML {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "format must be csv or yaml")
+		return
+	}
+	apply := r.URL.Query().Get("apply") == "true"
+
+	chart, err := orgchart.Parse(r.Body, format)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "INVALID_ORG_CHART", err.Error())
+		return
+	}
+
+	result, err := h.store.SyncOrgChart(r.Context(), chart, false)
+	if err == nil && apply {
+		userIDs, teamNames := result.Plan.Scope()
+		if !h.authorizeUserManager(w, r, userIDs, teamNames) {
+			return
+		}
+		result, err = h.store.SyncOrgChart(r.Context(), chart, true)
+	}
+	if err != nil {
+		if errors.Is(err, store.ErrInvalidOrgChart) {
+			h.respondError(w, http.StatusBadRequest, "INVALID_ORG_CHART", err.Error())
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	if result.Deactivation != nil {
+		h.alertLeads(r.Context(), result.Deactivation.LeadAlerts)
+	}
+
+	h.respondJSON(w, http.StatusOK, result)
+}
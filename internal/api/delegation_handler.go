@@ -0,0 +1,113 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"pr-reviewer/internal/store"
+)
+
+func (h *Handler) CreateDelegation(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID          string     `json:"user_id"`
+		DelegateID      string     `json:"delegate_id"`
+		StartsAt        *time.Time `json:"starts_at"`
+		EndsAt          time.Time  `json:"ends_at"`
+		TransferReviews bool       `json:"transfer_reviews"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid body")
+		return
+	}
+
+	if req.UserID == "" || req.DelegateID == "" || req.EndsAt.IsZero() {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "user_id, delegate_id and ends_at are required")
+		return
+	}
+	if req.UserID == req.DelegateID {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "a user cannot delegate to themselves")
+		return
+	}
+	now := time.Now()
+	startsAt := now
+	if req.StartsAt != nil {
+		startsAt = *req.StartsAt
+	}
+	if !req.EndsAt.After(startsAt) || !req.EndsAt.After(now) {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "ends_at must be in the future and after starts_at")
+		return
+	}
+	if !h.authorizeUserManager(w, r, []string{req.UserID, req.DelegateID}, nil) {
+		return
+	}
+
+	result, err := h.store.CreateDelegation(r.Context(), req.UserID, req.DelegateID, startsAt, req.EndsAt, req.TransferReviews)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			h.respondError(w, http.StatusNotFound, "NOT_FOUND", "user or delegate not found")
+		case errors.Is(err, store.ErrDelegationClash):
+			h.respondError(w, http.StatusConflict, "DELEGATION_CLASH", err.Error())
+		default:
+			h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, result)
+}
+
+func (h *Handler) ListDelegations(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	delegations, err := h.store.ListDelegations(r.Context(), q.Get("user_id"), q.Get("include_ended") == "true")
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]any{"delegations": delegations})
+}
+
+func (h *Handler) CancelDelegation(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		DelegationID string `json:"delegation_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid body")
+		return
+	}
+
+	if req.DelegationID == "" {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "delegation_id is required")
+		return
+	}
+	current, err := h.store.GetDelegation(r.Context(), req.DelegationID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			h.respondError(w, http.StatusNotFound, "NOT_FOUND", "delegation not found")
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	if !h.authorizeUserManager(w, r, []string{current.UserID, current.DelegateID}, nil) {
+		return
+	}
+
+	delegation, err := h.store.CancelDelegation(r.Context(), req.DelegationID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			h.respondError(w, http.StatusNotFound, "NOT_FOUND", "delegation not found")
+		case errors.Is(err, store.ErrDelegationEnded):
+			h.respondError(w, http.StatusConflict, "DELEGATION_ENDED", err.Error())
+		default:
+			h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]any{"delegation": delegation})
+}
@@ -5,15 +5,17 @@ import (
 	"net/http"
 
 	"pr-reviewer/internal/model"
+	"pr-reviewer/internal/notify"
 	"pr-reviewer/internal/store"
 )
 
 type Handler struct {
-	store *store.Store
+	store    *store.Store
+	notifier notify.Notifier
 }
 
-func NewHandler(store *store.Store) *Handler {
-	return &Handler{store: store}
+func NewHandler(store *store.Store, notifier notify.Notifier) *Handler {
+	return &Handler{store: store, notifier: notifier}
 }
 
 
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"pr-reviewer/internal/store"
+)
+
+func (h *Handler) SubmitBulkDeactivation(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserIDs []string `json:"user_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid body")
+		return
+	}
+
+	if len(req.UserIDs) == 0 {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "user_ids is required")
+		return
+	}
+	if !h.authorizeUserManager(w, r, req.UserIDs, nil) {
+		return
+	}
+
+	job, err := h.store.SubmitBulkDeactivation(r.Context(), req.UserIDs)
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusAccepted, map[string]any{"job": job})
+}
+
+func (h *Handler) SubmitBulkReactivation(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserIDs   []string `json:"user_ids"`
+		Rebalance bool     `json:"rebalance"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid body")
+		return
+	}
+
+	if len(req.UserIDs) == 0 {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "user_ids is required")
+		return
+	}
+	if !h.authorizeUserManager(w, r, req.UserIDs, nil) {
+		return
+	}
+
+	job, err := h.store.SubmitBulkReactivation(r.Context(), req.UserIDs, req.Rebalance)
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusAccepted, map[string]any{"job": job})
+}
+
+func (h *Handler) GetJob(w http.ResponseWriter, r *http.Request) {
+	jobID := r.URL.Query().Get("job_id")
+	if jobID == "" {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "job_id query param is required")
+		return
+	}
+
+	job, err := h.store.GetJob(r.Context(), jobID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			h.respondError(w, http.StatusNotFound, "NOT_FOUND", "job not found")
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]any{"job": job})
+}
+
+func (h *Handler) CancelJob(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		JobID string `json:"job_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid body")
+		return
+	}
+
+	if req.JobID == "" {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "job_id is required")
+		return
+	}
+
+	job, err := h.store.CancelJob(r.Context(), req.JobID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			h.respondError(w, http.StatusNotFound, "NOT_FOUND", "job not found")
+		case errors.Is(err, store.ErrJobFinished):
+			h.respondError(w, http.StatusConflict, "JOB_FINISHED", "job has already finished")
+		default:
+			h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]any{"job": job})
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"pr-reviewer/internal/notify"
+	"pr-reviewer/internal/store"
+)
+
+// actorHeader names the user performing a management operation. The service
+// does no authentication of its own: the header is trusted as sent, so it must
+// be set by an authenticating proxy in front of the service that strips any
+// value supplied by the client. Anyone who can reach the service directly can
+// act as any user.
+const actorHeader = "X-User-ID"
+
+func (h *Handler) SetTeamLeads(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TeamName string   `json:"team_name"`
+		UserIDs  []string `json:"user_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid request body")
+		return
+	}
+
+	if req.TeamName == "" {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "team_name is required")
+		return
+	}
+	if !h.authorizeTeamManager(w, r, req.TeamName) {
+		return
+	}
+
+	leads, err := h.store.SetTeamLeads(r.Context(), req.TeamName, req.UserIDs)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			h.respondError(w, http.StatusNotFound, "NOT_FOUND", "team not found")
+		case errors.Is(err, store.ErrUserNotFound):
+			h.respondError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+		case errors.Is(err, store.ErrTeamArchived):
+			h.respondError(w, http.StatusConflict, "TEAM_ARCHIVED", "team is archived")
+		case errors.Is(err, store.ErrLeadsRequired):
+			h.respondError(w, http.StatusConflict, "LEADS_REQUIRED", "turn off leads_only_management before removing all leads")
+		default:
+			h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]any{"team_name": req.TeamName, "leads": leads})
+}
+
+// authorizeTeamManager rejects the request with 403 unless the user in the
+// X-User-ID header may manage teamName. It reports whether to go on.
+func (h *Handler) authorizeTeamManager(w http.ResponseWriter, r *http.Request, teamName string) bool {
+	allowed, err := h.store.CanManageTeam(r.Context(), teamName, r.Header.Get(actorHeader))
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return false
+	}
+	if !allowed {
+		h.respondError(w, http.StatusForbidden, "FORBIDDEN", "only team leads may manage this team")
+		return false
+	}
+	return true
+}
+
+// authorizeUserManager rejects the request with 403 unless the user in the
+// X-User-ID header may manage every team that userIDs belong to and every
+// team in teamNames. It reports whether to go on.
+func (h *Handler) authorizeUserManager(w http.ResponseWriter, r *http.Request, userIDs, teamNames []string) bool {
+	restricted, err := h.store.RestrictedTeams(r.Context(), r.Header.Get(actorHeader), userIDs, teamNames)
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return false
+	}
+	if len(restricted) > 0 {
+		h.respondError(w, http.StatusForbidden, "FORBIDDEN", "only team leads may manage "+strings.Join(restricted, ", "))
+		return false
+	}
+	return true
+}
+
+// alertLeads tells the leads of the PR's team that a review has no
+// replacement. The alerts are sent in the background so that slow webhooks
+// do not hold up the response; they outlive the request's context.
+func (h *Handler) alertLeads(ctx context.Context, alerts []store.LeadAlert) {
+	if len(alerts) == 0 {
+		return
+	}
+	go notify.AlertLeads(context.WithoutCancel(ctx), h.notifier, alerts)
+}
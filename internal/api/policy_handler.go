@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"pr-reviewer/internal/model"
+	"pr-reviewer/internal/store"
+)
+
+func (h *Handler) GetTeamPolicy(w http.ResponseWriter, r *http.Request) {
+	teamName := r.URL.Query().Get("team_name")
+	if teamName == "" {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "team_name query param is required")
+		return
+	}
+
+	policy, err := h.store.GetTeamPolicy(r.Context(), teamName)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			h.respondError(w, http.StatusNotFound, "NOT_FOUND", "team not found")
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]any{"policy": policy})
+}
+
+func (h *Handler) SetTeamPolicy(w http.ResponseWriter, r *http.Request) {
+	var req model.TeamPolicy
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid request body")
+		return
+	}
+
+	if req.TeamName == "" {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "team_name is required")
+		return
+	}
+
+	if !h.authorizeTeamManager(w, r, req.TeamName) {
+		return
+	}
+
+	err := h.store.SetTeamPolicy(r.Context(), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			h.respondError(w, http.StatusNotFound, "NOT_FOUND", "team not found")
+		case errors.Is(err, store.ErrInvalidPolicy):
+			h.respondError(w, http.StatusBadRequest, "INVALID_POLICY", err.Error())
+		default:
+			h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]any{"policy": req})
+}
+
+// GetPolicyFile shows the policy file currently in use, so a reload can be
+// checked. policy_file is null when POLICY_FILE is not set.
+func (h *Handler) GetPolicyFile(w http.ResponseWriter, r *http.Request) {
+	h.respondJSON(w, http.StatusOK, map[string]any{"policy_file": h.store.PolicyFile()})
+}
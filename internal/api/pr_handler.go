@@ -3,12 +3,16 @@ package api
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 
 	"pr-reviewer/internal/model"
 	"pr-reviewer/internal/store"
 )
 
+// maxBatchSize caps the number of PRs in one /pullRequest/createBatch call.
+const maxBatchSize = 1000
+
 func (h *Handler) CreatePullRequest(w http.ResponseWriter, r *http.Request) {
 	var req model.PullRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -16,26 +20,117 @@ func (h *Handler) CreatePullRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.ID == "" || req.Name == "" || req.AuthorID == "" {
-		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "pull_request_id, pull_request_name, and author_id are required")
+	if msg := validatePullRequest(&req); msg != "" {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", msg)
 		return
 	}
 
 	err := h.store.CreatePullRequest(r.Context(), &req)
 	if err != nil {
-		if errors.Is(err, store.ErrNotFound) {
-			h.respondError(w, http.StatusNotFound, "NOT_FOUND", "author not found")
-			return
-		}
-		if errors.Is(err, store.ErrPRExists) {
-			h.respondError(w, http.StatusConflict, "PR_EXISTS", "PR id already exists")
-			return
+		status, code, msg := createPullRequestError(err)
+		h.respondError(w, status, code, msg)
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, map[string]any{"pr": req})
+}
+
+type batchItemResult struct {
+	Index int                `json:"index"`
+	PR    *model.PullRequest `json:"pr,omitempty"`
+	Error *model.ErrorDetail `json:"error,omitempty"`
+}
+
+func (h *Handler) CreatePullRequestBatch(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		PullRequests []model.PullRequest `json:"pull_requests"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid request body")
+		return
+	}
+
+	if len(req.PullRequests) == 0 {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "pull_requests is required")
+		return
+	}
+	if len(req.PullRequests) > maxBatchSize {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", fmt.Sprintf("at most %d pull_requests per batch", maxBatchSize))
+		return
+	}
+
+	results := make([]batchItemResult, len(req.PullRequests))
+	var valid []*model.PullRequest
+	var validIdx []int
+	for i := range req.PullRequests {
+		pr := &req.PullRequests[i]
+		results[i].Index = i
+		if msg := validatePullRequest(pr); msg != "" {
+			results[i].Error = &model.ErrorDetail{Code: "BAD_REQUEST", Message: msg}
+			continue
 		}
+		valid = append(valid, pr)
+		validIdx = append(validIdx, i)
+	}
+
+	errs, err := h.store.CreatePullRequests(r.Context(), valid)
+	if err != nil {
 		h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
 		return
 	}
 
-	h.respondJSON(w, http.StatusCreated, map[string]any{"pr": req})
+	created := 0
+	for k, i := range validIdx {
+		if errs[k] != nil {
+			_, code, msg := createPullRequestError(errs[k])
+			results[i].Error = &model.ErrorDetail{Code: code, Message: msg}
+			continue
+		}
+		results[i].PR = valid[k]
+		created++
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]any{
+		"created": created,
+		"failed":  len(results) - created,
+		"results": results,
+	})
+}
+
+// validatePullRequest checks a PR to create and returns what is wrong with
+// it, or "" if nothing is.
+func validatePullRequest(pr *model.PullRequest) string {
+	if pr.ID == "" || pr.Name == "" || pr.AuthorID == "" {
+		return "pull_request_id, pull_request_name, and author_id are required"
+	}
+	if pr.DependsOn == pr.ID {
+		return "a PR cannot depend on itself"
+	}
+	for _, n := range []*int{pr.LinesAdded, pr.LinesRemoved, pr.ChangedFiles} {
+		if n != nil && *n < 0 {
+			return "lines_added, lines_removed, and changed_files must not be negative"
+		}
+	}
+	return ""
+}
+
+// createPullRequestError maps an error of PR creation to its HTTP status and
+// error code.
+func createPullRequestError(err error) (int, string, string) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		return http.StatusNotFound, "NOT_FOUND", "author not found"
+	case errors.Is(err, store.ErrParentNotFound):
+		return http.StatusNotFound, "NOT_FOUND", "parent PR not found"
+	case errors.Is(err, store.ErrTeamNotFound):
+		return http.StatusNotFound, "NOT_FOUND", "target team not found"
+	case errors.Is(err, store.ErrTeamArchived):
+		return http.StatusConflict, "TEAM_ARCHIVED", "target team is archived"
+	case errors.Is(err, store.ErrPRExists):
+		return http.StatusConflict, "PR_EXISTS", "PR id already exists"
+	default:
+		return http.StatusInternalServerError, "INTERNAL_ERROR", err.Error()
+	}
 }
 
 func (h *Handler) MergePullRequest(w http.ResponseWriter, r *http.Request) {
@@ -53,6 +148,34 @@ func (h *Handler) MergePullRequest(w http.ResponseWriter, r *http.Request) {
 	}
 
 	pr, err := h.store.MergePullRequest(r.Context(), req.PullRequestID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			h.respondError(w, http.StatusNotFound, "NOT_FOUND", "PR not found")
+			return
+		}
+		if errors.Is(err, store.ErrPRClosed) {
+			h.respondError(w, http.StatusConflict, "PR_CLOSED", "cannot merge a closed PR")
+			return
+		}
+		if errors.Is(err, store.ErrParentNotMerged) {
+			h.respondError(w, http.StatusConflict, "PARENT_NOT_MERGED", "the PR this one depends on must be merged first")
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]any{"pr": pr})
+}
+
+func (h *Handler) GetPullRequest(w http.ResponseWriter, r *http.Request) {
+	prID := r.URL.Query().Get("pull_request_id")
+	if prID == "" {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "pull_request_id query param is required")
+		return
+	}
+
+	pr, err := h.store.GetPullRequest(r.Context(), prID)
 	if err != nil {
 		if errors.Is(err, store.ErrNotFound) {
 			h.respondError(w, http.StatusNotFound, "NOT_FOUND", "PR not found")
@@ -87,9 +210,14 @@ func (h *Handler) ReassignReviewer(w http.ResponseWriter, r *http.Request) {
 			h.respondError(w, http.StatusNotFound, "NOT_FOUND", "PR or user not found")
 		case errors.Is(err, store.ErrPRMerged):
 			h.respondError(w, http.StatusConflict, "PR_MERGED", "cannot reassign on merged PR")
+		case errors.Is(err, store.ErrPRClosed):
+			h.respondError(w, http.StatusConflict, "PR_CLOSED", "cannot reassign on closed PR")
 		case errors.Is(err, store.ErrNotAssigned):
 			h.respondError(w, http.StatusConflict, "NOT_ASSIGNED", "reviewer is not assigned to this PR")
 		case errors.Is(err, store.ErrNoCandidate):
+			if alert, err := h.store.NoCandidateAlert(r.Context(), req.PullRequestID, req.OldUserID); err == nil {
+				h.alertLeads(r.Context(), []store.LeadAlert{*alert})
+			}
 			h.respondError(w, http.StatusConflict, "NO_CANDIDATE", "no active replacement candidate in team")
 		default:
 			h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
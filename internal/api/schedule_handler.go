@@ -0,0 +1,110 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"pr-reviewer/internal/store"
+)
+
+func (h *Handler) ScheduleDeactivation(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID       string    `json:"user_id"`
+		DeactivateAt time.Time `json:"deactivate_at"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid body")
+		return
+	}
+
+	if req.UserID == "" || req.DeactivateAt.IsZero() {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "user_id and deactivate_at are required")
+		return
+	}
+	if !req.DeactivateAt.After(time.Now()) {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "deactivate_at must be in the future; use /users/setIsActive to deactivate now")
+		return
+	}
+	if !h.authorizeUserManager(w, r, []string{req.UserID}, nil) {
+		return
+	}
+
+	schedule, err := h.store.ScheduleDeactivation(r.Context(), req.UserID, req.DeactivateAt)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			h.respondError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]any{"schedule": schedule})
+}
+
+func (h *Handler) ListScheduledDeactivations(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	status := q.Get("status")
+	switch status {
+	case "":
+		status = store.SchedulePending
+	case "ALL":
+		status = ""
+	case store.SchedulePending, store.ScheduleDone, store.ScheduleCancelled:
+	default:
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "status must be PENDING, DONE, CANCELLED or ALL")
+		return
+	}
+
+	schedules, err := h.store.ListScheduledDeactivations(r.Context(), status, q.Get("user_id"))
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]any{"schedules": schedules})
+}
+
+func (h *Handler) CancelScheduledDeactivation(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ScheduleID string `json:"schedule_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid body")
+		return
+	}
+
+	if req.ScheduleID == "" {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "schedule_id is required")
+		return
+	}
+	current, err := h.store.GetScheduledDeactivation(r.Context(), req.ScheduleID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			h.respondError(w, http.StatusNotFound, "NOT_FOUND", "scheduled deactivation not found")
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	if !h.authorizeUserManager(w, r, []string{current.UserID}, nil) {
+		return
+	}
+
+	schedule, err := h.store.CancelScheduledDeactivation(r.Context(), req.ScheduleID)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			h.respondError(w, http.StatusNotFound, "NOT_FOUND", "scheduled deactivation not found")
+		case errors.Is(err, store.ErrScheduleClosed):
+			h.respondError(w, http.StatusConflict, "SCHEDULE_CLOSED", err.Error())
+		default:
+			h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]any{"schedule": schedule})
+}
@@ -2,13 +2,21 @@ package api
 
 import (
 	"net/http"
+
+	"pr-reviewer/internal/store"
 )
 
 func (h *Handler) GetStats(w http.ResponseWriter, r *http.Request) {
-	stats, err := h.store.GetSystemStats(r.Context())
+	var stats *store.Stats
+	var err error
+	if r.URL.Query().Get("include_archived") == "true" {
+		stats, err = h.store.GetHistoricalStats(r.Context())
+	} else {
+		stats, err = h.store.GetSystemStats(r.Context())
+	}
 	if err != nil {
 		h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
 		return
 	}
 	h.respondJSON(w, http.StatusOK, stats)
-}
\ No newline at end of file
+}
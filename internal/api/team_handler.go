@@ -27,6 +27,10 @@ func (h *Handler) CreateTeam(w http.ResponseWriter, r *http.Request) {
 			h.respondError(w, http.StatusBadRequest, "TEAM_EXISTS", "team_name already exists")
 			return
 		}
+		if errors.Is(err, store.ErrTeamNotFound) {
+			h.respondError(w, http.StatusNotFound, "NOT_FOUND", "parent team not found")
+			return
+		}
 		h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
 		return
 	}
@@ -54,6 +58,18 @@ func (h *Handler) GetTeam(w http.ResponseWriter, r *http.Request) {
 	h.respondJSON(w, http.StatusOK, team)
 }
 
+func (h *Handler) ListTeams(w http.ResponseWriter, r *http.Request) {
+	includeArchived := r.URL.Query().Get("include_archived") == "true"
+
+	teams, err := h.store.ListTeams(r.Context(), includeArchived)
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]any{"teams": teams})
+}
+
 func (h *Handler) BulkDeactivate(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		UserIDs []string `json:"user_ids"`
@@ -63,14 +79,285 @@ func (h *Handler) BulkDeactivate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := h.store.BulkDeactivateAndReassign(r.Context(), req.UserIDs)
+	if len(req.UserIDs) == 0 {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "user_ids is required")
+		return
+	}
+	if !h.authorizeUserManager(w, r, req.UserIDs, nil) {
+		return
+	}
+
+	report, err := h.store.BulkDeactivateAndReassign(r.Context(), req.UserIDs)
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	h.alertLeads(r.Context(), report.LeadAlerts)
+
+	h.respondJSON(w, http.StatusOK, report)
+}
+
+func (h *Handler) BulkReactivate(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserIDs   []string `json:"user_ids"`
+		Rebalance bool     `json:"rebalance"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid body")
+		return
+	}
+
+	if len(req.UserIDs) == 0 {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "user_ids is required")
+		return
+	}
+	if !h.authorizeUserManager(w, r, req.UserIDs, nil) {
+		return
+	}
+
+	reports, err := h.store.BulkReactivate(r.Context(), req.UserIDs, req.Rebalance)
+	if err != nil {
+		h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]any{"users": reports})
+}
+
+func (h *Handler) RebalanceTeam(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TeamName  string `json:"team_name"`
+		Threshold *int   `json:"threshold"`
+		DryRun    bool   `json:"dry_run"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid body")
+		return
+	}
+
+	if req.TeamName == "" {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "team_name is required")
+		return
+	}
+	threshold := 1
+	if req.Threshold != nil {
+		threshold = *req.Threshold
+	}
+	if threshold < 1 {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "threshold must be at least 1")
+		return
+	}
+
+	if !req.DryRun && !h.authorizeTeamManager(w, r, req.TeamName) {
+		return
+	}
+
+	result, err := h.store.RebalanceTeam(r.Context(), req.TeamName, threshold, req.DryRun)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			h.respondError(w, http.StatusNotFound, "NOT_FOUND", "team not found")
+		case errors.Is(err, store.ErrTeamArchived):
+			h.respondError(w, http.StatusConflict, "TEAM_ARCHIVED", "team is archived")
+		default:
+			h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, result)
+}
+
+func (h *Handler) AddTeamMembers(w http.ResponseWriter, r *http.Request) {
+	var req model.Team
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid request body")
+		return
+	}
+
+	if req.TeamName == "" || len(req.Members) == 0 {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "team_name and members are required")
+		return
+	}
+
+	if !h.authorizeTeamManager(w, r, req.TeamName) {
+		return
+	}
+
+	team, err := h.store.AddTeamMembers(r.Context(), req.TeamName, req.Members)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			h.respondError(w, http.StatusNotFound, "NOT_FOUND", "team not found")
+			return
+		}
+		if errors.Is(err, store.ErrTeamArchived) {
+			h.respondError(w, http.StatusConflict, "TEAM_ARCHIVED", "team is archived")
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]any{"team": team})
+}
+
+func (h *Handler) RemoveTeamMembers(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TeamName string   `json:"team_name"`
+		UserIDs  []string `json:"user_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid request body")
+		return
+	}
+
+	if req.TeamName == "" || len(req.UserIDs) == 0 {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "team_name and user_ids are required")
+		return
+	}
+
+	if !h.authorizeTeamManager(w, r, req.TeamName) {
+		return
+	}
+
+	result, err := h.store.RemoveTeamMembers(r.Context(), req.TeamName, req.UserIDs)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			h.respondError(w, http.StatusNotFound, "NOT_FOUND", "team not found")
+			return
+		}
+		if errors.Is(err, store.ErrTeamArchived) {
+			h.respondError(w, http.StatusConflict, "TEAM_ARCHIVED", "team is archived")
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, result)
+}
+
+func (h *Handler) RemoveTeam(w http.ResponseWriter, r *http.Request) {
+	var req store.TeamRemoval
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid request body")
+		return
+	}
+
+	if req.TeamName == "" {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "team_name is required")
+		return
+	}
+	if req.Mode != store.TeamRemovalArchive && req.Mode != store.TeamRemovalDelete {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "mode must be ARCHIVE or DELETE")
+		return
+	}
+	if req.MemberAction != store.MembersDeactivate && req.MemberAction != store.MembersMove {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "member_action must be DEACTIVATE or MOVE")
+		return
+	}
+	if req.PRAction != store.OpenPRsClose && req.PRAction != store.OpenPRsReassign {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "pr_action must be CLOSE or REASSIGN")
+		return
+	}
+	needsTarget := req.MemberAction == store.MembersMove || req.PRAction == store.OpenPRsReassign
+	if needsTarget && req.TargetTeam == "" {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "target_team is required to move members or reassign PRs")
+		return
+	}
+	if req.TargetTeam == req.TeamName {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "target_team must differ from team_name")
+		return
+	}
+
+	if !h.authorizeTeamManager(w, r, req.TeamName) {
+		return
+	}
+
+	result, err := h.store.RemoveTeam(r.Context(), req)
 	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			h.respondError(w, http.StatusNotFound, "NOT_FOUND", "team or target team not found")
+		case errors.Is(err, store.ErrTeamArchived):
+			h.respondError(w, http.StatusConflict, "TEAM_ARCHIVED", "team or target team is archived")
+		default:
+			h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, result)
+}
+
+func (h *Handler) SetMemberActive(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TeamName string `json:"team_name"`
+		UserID   string `json:"user_id"`
+		IsActive bool   `json:"is_active"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid request body")
+		return
+	}
+
+	if req.TeamName == "" || req.UserID == "" {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "team_name and user_id are required")
+		return
+	}
+
+	if !h.authorizeTeamManager(w, r, req.TeamName) {
+		return
+	}
+
+	member, err := h.store.SetMemberActive(r.Context(), req.TeamName, req.UserID, req.IsActive)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			h.respondError(w, http.StatusNotFound, "NOT_FOUND", "membership not found")
+			return
+		}
 		h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
 		return
 	}
 
-	h.respondJSON(w, http.StatusOK, map[string]any{
-		"deactivated_count": len(req.UserIDs),
-		"reassignments":     result,
-	})
-}
\ No newline at end of file
+	h.respondJSON(w, http.StatusOK, map[string]any{"team_name": req.TeamName, "member": member})
+}
+
+func (h *Handler) SetTeamParent(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		TeamName   string `json:"team_name"`
+		ParentTeam string `json:"parent_team"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid request body")
+		return
+	}
+
+	if req.TeamName == "" {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "team_name is required")
+		return
+	}
+
+	if !h.authorizeTeamManager(w, r, req.TeamName) {
+		return
+	}
+
+	team, err := h.store.SetTeamParent(r.Context(), req.TeamName, req.ParentTeam)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			h.respondError(w, http.StatusNotFound, "NOT_FOUND", "team not found")
+		case errors.Is(err, store.ErrTeamNotFound):
+			h.respondError(w, http.StatusNotFound, "NOT_FOUND", "parent team not found")
+		case errors.Is(err, store.ErrTeamArchived):
+			h.respondError(w, http.StatusConflict, "TEAM_ARCHIVED", "team or parent team is archived")
+		case errors.Is(err, store.ErrTeamCycle):
+			h.respondError(w, http.StatusConflict, "TEAM_CYCLE", err.Error())
+		default:
+			h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]any{"team": team})
+}
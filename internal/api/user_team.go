@@ -4,7 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strings"
 
+	"pr-reviewer/internal/model"
 	"pr-reviewer/internal/store"
 )
 
@@ -22,6 +24,9 @@ func (h *Handler) SetUserActive(w http.ResponseWriter, r *http.Request) {
 		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "user_id is required")
 		return
 	}
+	if !h.authorizeUserManager(w, r, []string{req.UserID}, nil) {
+		return
+	}
 
 	updatedUser, err := h.store.SetUserActive(r.Context(), req.UserID, req.IsActive)
 	if err != nil {
@@ -53,4 +58,173 @@ func (h *Handler) GetUserReviews(w http.ResponseWriter, r *http.Request) {
 		"user_id":       userID,
 		"pull_requests": prs,
 	})
-}
\ No newline at end of file
+}
+
+func (h *Handler) TransferUser(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID       string `json:"user_id"`
+		TeamName     string `json:"team_name"`
+		ReviewPolicy string `json:"review_policy"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid request body")
+		return
+	}
+
+	if req.UserID == "" || req.TeamName == "" {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "user_id and team_name are required")
+		return
+	}
+	if req.ReviewPolicy != store.TransferKeepReviews && req.ReviewPolicy != store.TransferReassignReviews {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "review_policy must be KEEP or REASSIGN")
+		return
+	}
+	if !h.authorizeUserManager(w, r, []string{req.UserID}, []string{req.TeamName}) {
+		return
+	}
+
+	result, err := h.store.TransferUser(r.Context(), req.UserID, req.TeamName, req.ReviewPolicy)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			h.respondError(w, http.StatusNotFound, "NOT_FOUND", "user or team not found")
+		case errors.Is(err, store.ErrAlreadyInTeam):
+			h.respondError(w, http.StatusConflict, "ALREADY_IN_TEAM", "user is already in this team")
+		case errors.Is(err, store.ErrTeamArchived):
+			h.respondError(w, http.StatusConflict, "TEAM_ARCHIVED", "team is archived")
+		default:
+			h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, result)
+}
+
+func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
+	userID := r.URL.Query().Get("user_id")
+	if userID == "" {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "user_id query param is required")
+		return
+	}
+
+	user, err := h.store.GetUser(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			h.respondError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]any{"user": user})
+}
+
+func (h *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID string `json:"user_id"`
+		model.UserUpdate
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid request body")
+		return
+	}
+
+	if req.UserID == "" {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "user_id is required")
+		return
+	}
+	if req.Username != nil && *req.Username == "" {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "username must not be empty")
+		return
+	}
+	if req.Email != nil && *req.Email != "" && !strings.Contains(*req.Email, "@") {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "email is invalid")
+		return
+	}
+	if !h.authorizeUserManager(w, r, []string{req.UserID}, nil) {
+		return
+	}
+
+	user, err := h.store.UpdateUser(r.Context(), req.UserID, req.UserUpdate)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			h.respondError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+		case errors.Is(err, store.ErrProfileTaken):
+			h.respondError(w, http.StatusConflict, "PROFILE_TAKEN", err.Error())
+		default:
+			h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		}
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, map[string]any{"user": user})
+}
+
+func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID string `json:"user_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid request body")
+		return
+	}
+
+	if req.UserID == "" {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "user_id is required")
+		return
+	}
+	if !h.authorizeUserManager(w, r, []string{req.UserID}, nil) {
+		return
+	}
+
+	result, err := h.store.DeleteUser(r.Context(), req.UserID)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			h.respondError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+			return
+		}
+		if errors.Is(err, store.ErrLeadsRequired) {
+			h.respondError(w, http.StatusConflict, "LEADS_REQUIRED", err.Error())
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+	h.alertLeads(r.Context(), result.LeadAlerts)
+
+	h.respondJSON(w, http.StatusOK, result)
+}
+
+func (h *Handler) HandoffReviews(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		UserID         string   `json:"user_id"`
+		PullRequestIDs []string `json:"pull_request_ids"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid request body")
+		return
+	}
+
+	if req.UserID == "" {
+		h.respondError(w, http.StatusBadRequest, "BAD_REQUEST", "user_id is required")
+		return
+	}
+	if !h.authorizeUserManager(w, r, []string{req.UserID}, nil) {
+		return
+	}
+
+	report, err := h.store.HandoffReviews(r.Context(), req.UserID, req.PullRequestIDs)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			h.respondError(w, http.StatusNotFound, "NOT_FOUND", "user not found")
+			return
+		}
+		h.respondError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, report)
+}
@@ -1,33 +1,76 @@
 package model
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type User struct {
-	ID       string `json:"user_id"`
-	Username string `json:"username"`
-	TeamName string `json:"team_name"`
-	IsActive bool   `json:"is_active"`
+	ID        string     `json:"user_id"`
+	Username  string     `json:"username"`
+	TeamName  string     `json:"team_name"`
+	IsActive  bool       `json:"is_active"`
+	IsSenior  bool       `json:"is_senior"`
+	Email     string     `json:"email,omitempty"`
+	Handle    string     `json:"handle,omitempty"`
+	Teams     []string   `json:"teams,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	// Delegations lists the current and upcoming delegations given or
+	// received by the user.
+	Delegations []Delegation `json:"delegations,omitempty"`
+}
+
+// UserUpdate changes the given profile fields; nil fields are left as they
+// are and empty email or handle clear them.
+type UserUpdate struct {
+	Username *string `json:"username"`
+	Email    *string `json:"email"`
+	Handle   *string `json:"handle"`
 }
 
 type TeamMember struct {
-	UserID   string `json:"user_id"`
-	Username string `json:"username"`
-	IsActive bool   `json:"is_active"`
+	UserID    string `json:"user_id"`
+	Username  string `json:"username"`
+	IsActive  bool   `json:"is_active"`
+	IsSenior  bool   `json:"is_senior"`
+	IsPrimary bool   `json:"is_primary,omitempty"`
 }
 
 type Team struct {
-	TeamName string       `json:"team_name"`
-	Members  []TeamMember `json:"members"`
+	TeamName   string       `json:"team_name"`
+	ParentTeam string       `json:"parent_team,omitempty"`
+	ChildTeams []string     `json:"child_teams,omitempty"`
+	Leads      []string     `json:"leads,omitempty"`
+	Members    []TeamMember `json:"members"`
+	ArchivedAt *time.Time   `json:"archived_at,omitempty"`
+}
+
+type TeamSummary struct {
+	TeamName          string     `json:"team_name"`
+	ParentTeam        string     `json:"parent_team,omitempty"`
+	MemberCount       int        `json:"member_count"`
+	ActiveMemberCount int        `json:"active_member_count"`
+	OpenPRsAuthored   int        `json:"open_prs_authored"`
+	OpenReviews       int        `json:"open_reviews"`
+	ArchivedAt        *time.Time `json:"archived_at,omitempty"`
 }
 
 type PullRequest struct {
-	ID                string     `json:"pull_request_id"`
-	Name              string     `json:"pull_request_name"`
-	AuthorID          string     `json:"author_id"`
-	Status            string     `json:"status"`
-	AssignedReviewers []string   `json:"assigned_reviewers"`
-	CreatedAt         *time.Time `json:"createdAt,omitempty"`
-	MergedAt          *time.Time `json:"mergedAt,omitempty"`
+	ID                string             `json:"pull_request_id"`
+	Name              string             `json:"pull_request_name"`
+	AuthorID          string             `json:"author_id"`
+	Status            string             `json:"status"`
+	AssignedReviewers []string           `json:"assigned_reviewers"`
+	LinesAdded        *int               `json:"lines_added,omitempty"`
+	LinesRemoved      *int               `json:"lines_removed,omitempty"`
+	ChangedFiles      *int               `json:"changed_files,omitempty"`
+	Size              string             `json:"size,omitempty"`
+	DependsOn         string             `json:"depends_on,omitempty"`
+	TargetTeam        string             `json:"target_team,omitempty"`
+	InheritReviewers  bool               `json:"inherit_reviewers,omitempty"`
+	DependencyChain   []PullRequestShort `json:"dependency_chain,omitempty"`
+	CreatedAt         *time.Time         `json:"createdAt,omitempty"`
+	MergedAt          *time.Time         `json:"mergedAt,omitempty"`
 }
 
 type PullRequestShort struct {
@@ -44,4 +87,77 @@ type ErrorResponse struct {
 type ErrorDetail struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
-}
\ No newline at end of file
+}
+
+type TeamPolicy struct {
+	TeamName                string     `json:"team_name"`
+	ReviewSLAHours          *int       `json:"review_sla_hours"`
+	SLAAction               string     `json:"sla_action"`
+	ReminderIntervalMinutes *int       `json:"reminder_interval_minutes"`
+	ReminderThresholdHours  *int       `json:"reminder_threshold_hours"`
+	SizeRules               []SizeRule `json:"size_rules"`
+	NoCandidateAction       string     `json:"no_candidate_action"`
+	LeadsOnlyManagement     bool       `json:"leads_only_management"`
+	// Timezone is the IANA name in which working hours are counted.
+	Timezone string `json:"timezone"`
+	// WorkDayStart and WorkDayEnd bound the working hours of a weekday, as
+	// whole hours in Timezone.
+	WorkDayStart *int `json:"work_day_start"`
+	WorkDayEnd   *int `json:"work_day_end"`
+}
+
+type SizeRule struct {
+	Size           string `json:"size"`
+	ReviewerCount  int    `json:"reviewer_count"`
+	SeniorRequired bool   `json:"senior_required"`
+}
+
+type ReminderEvent struct {
+	TeamName     string             `json:"team_name"`
+	ReviewerID   string             `json:"reviewer_id"`
+	Username     string             `json:"username"`
+	PullRequests []PullRequestShort `json:"pull_requests"`
+}
+
+// Delegation sends the reviews of UserID to DelegateID between StartsAt and
+// EndsAt. Status is SCHEDULED, ACTIVE, EXPIRED or CANCELLED.
+type Delegation struct {
+	DelegationID    string     `json:"delegation_id"`
+	UserID          string     `json:"user_id"`
+	DelegateID      string     `json:"delegate_id"`
+	StartsAt        time.Time  `json:"starts_at"`
+	EndsAt          time.Time  `json:"ends_at"`
+	Status          string     `json:"status"`
+	TransferReviews bool       `json:"transfer_reviews"`
+	TransferredAt   *time.Time `json:"transferred_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	CancelledAt     *time.Time `json:"cancelled_at,omitempty"`
+}
+
+// ScheduledDeactivation is a user's offboarding date. Result holds the
+// user's deactivation report once it has run.
+type ScheduledDeactivation struct {
+	ScheduleID   string          `json:"schedule_id"`
+	UserID       string          `json:"user_id"`
+	DeactivateAt time.Time       `json:"deactivate_at"`
+	Status       string          `json:"status"`
+	Result       json.RawMessage `json:"result,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+	FinishedAt   *time.Time      `json:"finished_at,omitempty"`
+}
+
+// Job is a bulk operation processed in the background.
+type Job struct {
+	JobID           string          `json:"job_id"`
+	Type            string          `json:"type"`
+	Status          string          `json:"status"`
+	Payload         json.RawMessage `json:"payload"`
+	Result          json.RawMessage `json:"result,omitempty"`
+	Error           string          `json:"error,omitempty"`
+	Total           int             `json:"total"`
+	Processed       int             `json:"processed"`
+	CancelRequested bool            `json:"cancel_requested"`
+	CreatedAt       time.Time       `json:"created_at"`
+	StartedAt       *time.Time      `json:"started_at,omitempty"`
+	FinishedAt      *time.Time      `json:"finished_at,omitempty"`
+}
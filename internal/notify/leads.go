@@ -0,0 +1,31 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"pr-reviewer/internal/store"
+)
+
+// AlertLeads tells the leads of the PR's team that a review has no
+// replacement. Delivery failures are only logged.
+func AlertLeads(ctx context.Context, n Notifier, alerts []store.LeadAlert) {
+	now := time.Now()
+	for _, alert := range alerts {
+		for _, lead := range alert.Leads {
+			event := Event{
+				Type:      TypeNoCandidate,
+				TeamName:  alert.TeamName,
+				UserID:    lead,
+				Message:   fmt.Sprintf("no replacement found for %s on %s", alert.ReviewerID, alert.PullRequestID),
+				Data:      alert,
+				CreatedAt: now,
+			}
+			if err := n.Notify(ctx, event); err != nil {
+				log.Printf("notifying lead %s failed: %v", lead, err)
+			}
+		}
+	}
+}
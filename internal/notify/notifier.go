@@ -0,0 +1,35 @@
+package notify
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	TypeReviewReminder = "REVIEW_REMINDER"
+	TypeNoCandidate    = "NO_CANDIDATE"
+)
+
+// Event is a message addressed to a single user.
+type Event struct {
+	Type      string    `json:"type"`
+	TeamName  string    `json:"team_name"`
+	UserID    string    `json:"user_id"`
+	Message   string    `json:"message"`
+	Data      any       `json:"data,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Notifier delivers events to users.
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// LogNotifier writes events to the server log.
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(ctx context.Context, event Event) error {
+	log.Printf("notify [%s] team=%s user=%s: %s", event.Type, event.TeamName, event.UserID, event.Message)
+	return nil
+}
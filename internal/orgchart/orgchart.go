@@ -0,0 +1,120 @@
+// Package orgchart reads org charts from CSV and YAML files.
+package orgchart
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"pr-reviewer/internal/store"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatYAML = "yaml"
+)
+
+// FormatOf guesses the format from a file name's extension.
+func FormatOf(name string) (string, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	}
+	return "", fmt.Errorf("cannot tell the format of %q, expected .csv, .yaml or .yml", name)
+}
+
+// Parse reads a chart in the given format. Malformed input is reported as
+// store.ErrInvalidOrgChart.
+func Parse(r io.Reader, format string) (*store.OrgChart, error) {
+	switch format {
+	case FormatCSV:
+		return parseCSV(r)
+	case FormatYAML:
+		return parseYAML(r)
+	}
+	return nil, fmt.Errorf("unknown org chart format %q", format)
+}
+
+// parseYAML reads a document with a `teams` list (team_name, parent_team) and
+// a `users` list (user_id, username, team_name, is_active).
+func parseYAML(r io.Reader) (*store.OrgChart, error) {
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+
+	var chart store.OrgChart
+	if err := dec.Decode(&chart); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: %v", store.ErrInvalidOrgChart, err)
+	}
+	return &chart, nil
+}
+
+// parseCSV reads one user per row. The header must name the user_id,
+// username and team_name columns and may name is_active; other columns are
+// ignored.
+func parseCSV(r io.Reader) (*store.OrgChart, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: empty file", store.ErrInvalidOrgChart)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", store.ErrInvalidOrgChart, err)
+	}
+	cols := make(map[string]int)
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range []string{"user_id", "username", "team_name"} {
+		if _, ok := cols[required]; !ok {
+			return nil, fmt.Errorf("%w: missing column %s", store.ErrInvalidOrgChart, required)
+		}
+	}
+
+	chart := &store.OrgChart{}
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", store.ErrInvalidOrgChart, err)
+		}
+		line, _ := cr.FieldPos(0)
+
+		u := store.OrgUser{
+			UserID:   strings.TrimSpace(record[cols["user_id"]]),
+			Username: strings.TrimSpace(record[cols["username"]]),
+			TeamName: strings.TrimSpace(record[cols["team_name"]]),
+		}
+		if i, ok := cols["is_active"]; ok {
+			if v := strings.TrimSpace(record[i]); v != "" {
+				active, err := parseBool(v)
+				if err != nil {
+					return nil, fmt.Errorf("%w: line %d: is_active: %v", store.ErrInvalidOrgChart, line, err)
+				}
+				u.IsActive = &active
+			}
+		}
+		chart.Users = append(chart.Users, u)
+	}
+	return chart, nil
+}
+
+func parseBool(v string) (bool, error) {
+	switch strings.ToLower(v) {
+	case "yes", "y":
+		return true, nil
+	case "no", "n":
+		return false, nil
+	}
+	return strconv.ParseBool(v)
+}
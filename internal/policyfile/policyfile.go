@@ -0,0 +1,109 @@
+// Package policyfile loads assignment policies from a YAML file and reloads
+// them when the file changes or the process receives SIGHUP.
+package policyfile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"pr-reviewer/internal/store"
+)
+
+// Load reads and validates the policy file at path. Unknown keys and invalid
+// values are reported as store.ErrInvalidPolicy.
+func Load(path string) (*store.PolicyFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	pf, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	pf.Path = path
+	pf.LoadedAt = time.Now()
+	return pf, nil
+}
+
+// Parse reads and validates a policy file.
+func Parse(r io.Reader) (*store.PolicyFile, error) {
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+
+	var pf store.PolicyFile
+	if err := dec.Decode(&pf); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: %v", store.ErrInvalidPolicy, err)
+	}
+	if err := pf.Validate(); err != nil {
+		return nil, err
+	}
+	return &pf, nil
+}
+
+// Watcher reloads the policy file into the store whenever a signal arrives on
+// hup and whenever the file's modification time changes. A file that fails to
+// load, or names a fallback team that does not exist, is logged and the
+// policies already in use are kept.
+type Watcher struct {
+	store    *store.Store
+	path     string
+	interval time.Duration
+	hup      <-chan os.Signal
+}
+
+// NewWatcher returns a watcher for the policy file at path. The caller
+// registers hup for SIGHUP, before the initial load, so a reload requested
+// during startup is not lost.
+func NewWatcher(st *store.Store, path string, interval time.Duration, hup <-chan os.Signal) *Watcher {
+	return &Watcher{store: st, path: path, interval: interval, hup: hup}
+}
+
+func (w *Watcher) Run(ctx context.Context) {
+	modTime := w.modTime()
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.hup:
+			log.Printf("Policy watcher: SIGHUP received, reloading %s", w.path)
+			modTime = w.modTime()
+			w.reload(ctx)
+		case <-ticker.C:
+			if t := w.modTime(); !t.Equal(modTime) {
+				modTime = t
+				w.reload(ctx)
+			}
+		}
+	}
+}
+
+func (w *Watcher) modTime() time.Time {
+	info, err := os.Stat(w.path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+func (w *Watcher) reload(ctx context.Context) {
+	pf, err := Load(w.path)
+	if err == nil {
+		err = w.store.ApplyPolicyFile(ctx, pf)
+	}
+	if err != nil {
+		log.Printf("Policy watcher: keeping current policies, reload failed: %v", err)
+		return
+	}
+	log.Printf("Policy watcher: loaded %s (%d team override(s))", w.path, len(pf.Teams))
+}
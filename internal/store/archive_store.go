@@ -0,0 +1,96 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+type ArchiveResult struct {
+	ArchivedPRs     int `json:"archived_prs"`
+	ArchivedReviews int `json:"archived_reviews"`
+}
+
+// ArchivePullRequests moves merged and closed PRs last touched before cutoff,
+// together with their reviewer assignments, into the archive tables. PRs that
+// live PRs still depend on are kept until their dependents are archived too.
+// PRs whose id is already in the archive (reused before ids were reserved)
+// stay live rather than block the whole run.
+func (s *Store) ArchivePullRequests(ctx context.Context, cutoff time.Time) (*ArchiveResult, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		WITH RECURSIVE expired AS (
+			SELECT id FROM pull_requests
+			WHERE status <> 'OPEN' AND COALESCE(merged_at, closed_at, created_at) < $1
+			  AND id NOT IN (SELECT id FROM pull_requests_archive)
+		), blocked AS (
+			SELECT p.parent_id AS id
+			FROM pull_requests p
+			WHERE p.parent_id IS NOT NULL AND p.id NOT IN (SELECT id FROM expired)
+			UNION
+			SELECT p.parent_id
+			FROM pull_requests p
+			JOIN blocked b ON p.id = b.id
+			WHERE p.parent_id IS NOT NULL
+		)
+		SELECT id FROM expired
+		WHERE id NOT IN (SELECT id FROM blocked)
+	`, cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	var prIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		prIDs = append(prIDs, id)
+	}
+	rows.Close()
+
+	result := &ArchiveResult{}
+	if len(prIDs) == 0 {
+		return result, nil
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO reviewers_archive (pull_request_id, user_id, assigned_at, due_at, escalated_at)
+		SELECT pull_request_id, user_id, assigned_at, due_at, escalated_at
+		FROM reviewers WHERE pull_request_id = ANY($1)
+	`, prIDs)
+	if err != nil {
+		return nil, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	result.ArchivedReviews = int(n)
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO pull_requests_archive
+			(id, name, author_id, status, created_at, merged_at, closed_at, lines_added, lines_removed, changed_files, size, parent_id, team_name)
+		SELECT id, name, author_id, status, created_at, merged_at, closed_at, lines_added, lines_removed, changed_files, size, parent_id, team_name
+		FROM pull_requests WHERE id = ANY($1)
+	`, prIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM reviewers WHERE pull_request_id = ANY($1)", prIDs); err != nil {
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM pull_requests WHERE id = ANY($1)", prIDs); err != nil {
+		return nil, err
+	}
+
+	result.ArchivedPRs = len(prIDs)
+	return result, tx.Commit()
+}
@@ -0,0 +1,192 @@
+package store
+
+import (
+	"context"
+	"time"
+)
+
+// candidateQuery describes which users may be picked as reviewers.
+type candidateQuery struct {
+	TeamName   string
+	PrID       string // users already reviewing this PR are skipped
+	Exclude    []string
+	SeniorOnly bool
+	Limit      int
+	Lineage    []string // teams to search, from candidateLineage; looked up when nil
+}
+
+// maxTeamDepth bounds the walk up the team hierarchy.
+const maxTeamDepth = 16
+
+// pickCandidates returns up to cq.Limit members of cq.TeamName matching the
+// query. If the team is short of candidates the search continues with its
+// parent team, then the grandparent, and so on, and finally with the team's
+// fallback team from the policy file and its ancestors. A picked user with an
+// active delegation is replaced by their delegate when the delegate is
+// eligible (see delegateFor).
+func (s *Store) pickCandidates(ctx context.Context, q querier, cq candidateQuery) ([]string, error) {
+	if cq.TeamName == "" {
+		return nil, nil
+	}
+	lineage := cq.Lineage
+	if lineage == nil {
+		var err error
+		if lineage, err = s.candidateLineage(ctx, q, cq.TeamName); err != nil {
+			return nil, err
+		}
+	}
+
+	var picked, delegated []string
+	for _, team := range lineage {
+		if len(picked) >= cq.Limit {
+			break
+		}
+		level := cq
+		level.TeamName = team
+		level.Exclude = append(append(append([]string{}, cq.Exclude...), picked...), delegated...)
+		level.Limit = cq.Limit - len(picked)
+		ids, err := s.pickFromTeam(ctx, q, level)
+		if err != nil {
+			return nil, err
+		}
+		for i, id := range ids {
+			taken := append(append(append(append([]string{}, level.Exclude...), picked...), delegated...), ids[i:]...)
+			delegate, err := s.delegateFor(ctx, q, id, level, taken)
+			if err != nil {
+				return nil, err
+			}
+			if delegate != "" {
+				delegated = append(delegated, id)
+				id = delegate
+			}
+			picked = append(picked, id)
+		}
+	}
+	return picked, nil
+}
+
+// candidateLineage returns the teams pickCandidates searches for teamName, in
+// order: the team, its ancestors, then its fallback team and the fallback's
+// ancestors.
+func (s *Store) candidateLineage(ctx context.Context, q querier, teamName string) ([]string, error) {
+	lineage, err := teamLineage(ctx, q, teamName)
+	if err != nil {
+		return nil, err
+	}
+	if fallback := s.assignmentPolicy(teamName).fallbackTeam; fallback != "" {
+		more, err := teamLineage(ctx, q, fallback)
+		if err != nil {
+			return nil, err
+		}
+		seen := make(map[string]bool)
+		for _, team := range lineage {
+			seen[team] = true
+		}
+		for _, team := range more {
+			if !seen[team] {
+				lineage = append(lineage, team)
+			}
+		}
+	}
+	return lineage, nil
+}
+
+// teamLineage returns teamName followed by its ancestors, nearest first.
+func teamLineage(ctx context.Context, q querier, teamName string) ([]string, error) {
+	return queryIDs(ctx, q, `
+		WITH RECURSIVE up AS (
+			SELECT name, parent_team, 0 AS depth FROM teams WHERE name = $1
+			UNION ALL
+			SELECT t.name, t.parent_team, up.depth + 1
+			FROM teams t JOIN up ON t.name = up.parent_team
+			WHERE up.depth < $2
+		)
+		SELECT name FROM up ORDER BY depth
+	`, teamName, maxTeamDepth)
+}
+
+// pickFromTeam returns up to cq.Limit members of cq.TeamName matching the
+// query, ordered and capped by the team's file policy. Both the user and
+// their membership in the team must be active, and the user must not have a
+// pending scheduled deactivation.
+func (s *Store) pickFromTeam(ctx context.Context, q querier, cq candidateQuery) ([]string, error) {
+	exclude := cq.Exclude
+	if exclude == nil {
+		exclude = []string{}
+	}
+	policy := s.assignmentPolicy(cq.TeamName)
+	rows, err := q.QueryContext(ctx, `
+		SELECT u.id
+		FROM team_memberships m
+		JOIN users u ON m.user_id = u.id
+		CROSS JOIN LATERAL (
+			SELECT count(*) AS open_reviews
+			FROM reviewers r
+			JOIN pull_requests p ON r.pull_request_id = p.id
+			WHERE r.user_id = u.id AND p.status = 'OPEN'
+		) load
+		WHERE m.team_name = $1
+		  AND m.is_active = true
+		  AND u.is_active = true
+		  AND u.id <> ALL($2)
+		  AND u.id NOT IN (SELECT user_id FROM reviewers WHERE pull_request_id = $3)
+		  AND (NOT $4 OR u.is_senior)
+		  AND `+notLeaving+`
+		  AND ($6::int = 0 OR load.open_reviews < $6::int)
+		ORDER BY CASE WHEN $7::boolean THEN load.open_reviews END, RANDOM()
+		LIMIT $5
+	`, cq.TeamName, exclude, cq.PrID, cq.SeniorOnly, cq.Limit, policy.maxOpenReviews, policy.strategy == StrategyLeastLoaded)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// insertReviewer assigns userID to prID with the review deadline of teamName.
+func (s *Store) insertReviewer(ctx context.Context, q querier, prID, userID, teamName string) error {
+	dueAt, err := s.reviewDeadline(ctx, q, teamName, time.Now())
+	if err != nil {
+		return err
+	}
+	_, err = q.ExecContext(ctx,
+		"INSERT INTO reviewers (pull_request_id, user_id, due_at) VALUES ($1, $2, $3)",
+		prID, userID, dueAt,
+	)
+	return err
+}
+
+// insertReviewers assigns all userIDs to prID in one statement, with the
+// review deadline of teamName.
+func (s *Store) insertReviewers(ctx context.Context, q querier, prID string, userIDs []string, teamName string) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	dueAt, err := s.reviewDeadline(ctx, q, teamName, time.Now())
+	if err != nil {
+		return err
+	}
+	return insertReviewersDue(ctx, q, prID, userIDs, dueAt)
+}
+
+// insertReviewersDue assigns all userIDs to prID in one statement, due at
+// dueAt.
+func insertReviewersDue(ctx context.Context, q querier, prID string, userIDs []string, dueAt *time.Time) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	_, err := q.ExecContext(ctx,
+		"INSERT INTO reviewers (pull_request_id, user_id, due_at) SELECT $1, unnest($2::varchar[]), $3",
+		prID, userIDs, dueAt,
+	)
+	return err
+}
@@ -0,0 +1,286 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"pr-reviewer/internal/model"
+)
+
+const (
+	DelegationScheduled = "SCHEDULED"
+	DelegationActive    = "ACTIVE"
+	DelegationExpired   = "EXPIRED"
+	DelegationCancelled = "CANCELLED"
+)
+
+const delegationColumns = `id, user_id, delegate_id, starts_at, ends_at,
+	CASE
+		WHEN cancelled_at IS NOT NULL THEN 'CANCELLED'
+		WHEN ends_at <= CURRENT_TIMESTAMP THEN 'EXPIRED'
+		WHEN starts_at > CURRENT_TIMESTAMP THEN 'SCHEDULED'
+		ELSE 'ACTIVE'
+	END,
+	transfer_reviews, transferred_at, created_at, cancelled_at`
+
+// activeDelegation is a condition on the review_delegations row d that holds
+// while the delegation is in force.
+const activeDelegation = "d.cancelled_at IS NULL AND d.starts_at <= CURRENT_TIMESTAMP AND d.ends_at > CURRENT_TIMESTAMP"
+
+func scanDelegation(row interface{ Scan(...any) error }) (*model.Delegation, error) {
+	var d model.Delegation
+	err := row.Scan(&d.DelegationID, &d.UserID, &d.DelegateID, &d.StartsAt, &d.EndsAt, &d.Status,
+		&d.TransferReviews, &d.TransferredAt, &d.CreatedAt, &d.CancelledAt)
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+func queryDelegations(ctx context.Context, q querier, query string, args ...any) ([]model.Delegation, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []model.Delegation{}
+	for rows.Next() {
+		d, err := scanDelegation(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *d)
+	}
+	return list, rows.Err()
+}
+
+// DelegationTransfer lists the open reviews handed to the delegate when a
+// delegation with transfer_reviews started, and the PRs whose review stayed
+// with the user because the delegate could not take it.
+type DelegationTransfer struct {
+	DelegationID string       `json:"delegation_id"`
+	Moves        []ReviewMove `json:"moves"`
+	KeptReviews  []string     `json:"kept_reviews"`
+}
+
+type DelegationResult struct {
+	Delegation *model.Delegation   `json:"delegation"`
+	Transfer   *DelegationTransfer `json:"transfer,omitempty"`
+}
+
+// CreateDelegation sends the user's new reviews to delegateID between
+// startsAt and endsAt. A user can have only one delegation at a time
+// (ErrDelegationClash). With transferReviews and a window that has already
+// started, the user's open reviews move to the delegate right away; for
+// later windows the delegation worker moves them when the window starts.
+func (s *Store) CreateDelegation(ctx context.Context, userID, delegateID string, startsAt, endsAt time.Time, transferReviews bool) (*DelegationResult, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	found, err := queryIDs(ctx, tx,
+		"SELECT id FROM users WHERE id = ANY($1) AND deleted_at IS NULL ORDER BY id FOR UPDATE",
+		[]string{userID, delegateID},
+	)
+	if err != nil {
+		return nil, err
+	}
+	if len(found) != 2 {
+		return nil, ErrNotFound
+	}
+
+	var clash bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM review_delegations
+			WHERE user_id = $1 AND cancelled_at IS NULL AND starts_at < $3 AND ends_at > $2
+		)
+	`, userID, startsAt, endsAt).Scan(&clash)
+	if err != nil {
+		return nil, err
+	}
+	if clash {
+		return nil, ErrDelegationClash
+	}
+
+	d, err := scanDelegation(tx.QueryRowContext(ctx, `
+		INSERT INTO review_delegations (user_id, delegate_id, starts_at, ends_at, transfer_reviews)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING `+delegationColumns, userID, delegateID, startsAt, endsAt, transferReviews))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DelegationResult{Delegation: d}
+	if transferReviews && d.Status == DelegationActive {
+		result.Transfer, err = s.transferToDelegate(ctx, tx, d)
+		if err != nil {
+			return nil, err
+		}
+		result.Delegation, err = scanDelegation(tx.QueryRowContext(ctx,
+			"SELECT "+delegationColumns+" FROM review_delegations WHERE id = $1", d.DelegationID,
+		))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, tx.Commit()
+}
+
+// transferToDelegate moves the user's reviews on OPEN PRs to the delegate
+// and marks the delegation as transferred. Reviews stay with the user when
+// the delegate is inactive, leaving, the PR's author or already reviewing.
+func (s *Store) transferToDelegate(ctx context.Context, tx *sql.Tx, d *model.Delegation) (*DelegationTransfer, error) {
+	transfer := &DelegationTransfer{DelegationID: d.DelegationID, Moves: []ReviewMove{}, KeptReviews: []string{}}
+
+	tasks, err := s.openAssignments(ctx, tx, []string{d.UserID}, "")
+	if err != nil {
+		return nil, err
+	}
+	var eligible bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM users u WHERE u.id = $1 AND u.is_active AND u.deleted_at IS NULL AND `+notLeaving+`)
+	`, d.DelegateID).Scan(&eligible)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, task := range tasks {
+		if !eligible || task.AuthorID == d.DelegateID {
+			transfer.KeptReviews = append(transfer.KeptReviews, task.PrID)
+			continue
+		}
+		dueAt, err := s.reviewDeadline(ctx, tx, task.TeamName, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		res, err := tx.ExecContext(ctx, `
+			UPDATE reviewers SET user_id = $1, assigned_at = CURRENT_TIMESTAMP, due_at = $4, escalated_at = NULL
+			WHERE pull_request_id = $2 AND user_id = $3
+			  AND NOT EXISTS (SELECT 1 FROM reviewers WHERE pull_request_id = $2 AND user_id = $1)
+		`, d.DelegateID, task.PrID, d.UserID, dueAt)
+		if err != nil {
+			return nil, err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return nil, err
+		} else if n == 0 {
+			transfer.KeptReviews = append(transfer.KeptReviews, task.PrID)
+			continue
+		}
+		transfer.Moves = append(transfer.Moves, ReviewMove{PullRequestID: task.PrID, FromUserID: d.UserID, ToUserID: d.DelegateID})
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE review_delegations SET transferred_at = CURRENT_TIMESTAMP WHERE id = $1", d.DelegationID)
+	if err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// StartDueDelegations transfers the open reviews of every delegation with
+// transfer_reviews whose window has started and not yet ended.
+func (s *Store) StartDueDelegations(ctx context.Context) ([]DelegationTransfer, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	due, err := queryDelegations(ctx, tx, `
+		SELECT `+delegationColumns+` FROM review_delegations
+		WHERE transfer_reviews AND transferred_at IS NULL AND cancelled_at IS NULL
+		  AND starts_at <= CURRENT_TIMESTAMP AND ends_at > CURRENT_TIMESTAMP
+		ORDER BY starts_at, id
+		FOR UPDATE SKIP LOCKED
+	`)
+	if err != nil {
+		return nil, err
+	}
+
+	transfers := []DelegationTransfer{}
+	for i := range due {
+		t, err := s.transferToDelegate(ctx, tx, &due[i])
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, *t)
+	}
+	return transfers, tx.Commit()
+}
+
+// ListDelegations returns the delegations given or received by userID, or
+// everyone's if userID is empty, soonest first. Unless includeEnded is set,
+// expired and cancelled ones are left out.
+func (s *Store) ListDelegations(ctx context.Context, userID string, includeEnded bool) ([]model.Delegation, error) {
+	return listDelegations(ctx, s.db, userID, includeEnded)
+}
+
+func listDelegations(ctx context.Context, q querier, userID string, includeEnded bool) ([]model.Delegation, error) {
+	return queryDelegations(ctx, q, `
+		SELECT `+delegationColumns+` FROM review_delegations
+		WHERE ($1 = '' OR user_id = $1 OR delegate_id = $1)
+		  AND ($2 OR (cancelled_at IS NULL AND ends_at > CURRENT_TIMESTAMP))
+		ORDER BY starts_at, id
+	`, userID, includeEnded)
+}
+
+// GetDelegation returns a delegation in any status.
+func (s *Store) GetDelegation(ctx context.Context, id string) (*model.Delegation, error) {
+	d, err := scanDelegation(s.db.QueryRowContext(ctx, "SELECT "+delegationColumns+" FROM review_delegations WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return d, err
+}
+
+// CancelDelegation ends a scheduled or active delegation. Reviews already
+// transferred stay with the delegate. Expired and cancelled delegations give
+// ErrDelegationEnded.
+func (s *Store) CancelDelegation(ctx context.Context, id string) (*model.Delegation, error) {
+	d, err := scanDelegation(s.db.QueryRowContext(ctx, `
+		UPDATE review_delegations SET cancelled_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND cancelled_at IS NULL AND ends_at > CURRENT_TIMESTAMP
+		RETURNING `+delegationColumns, id))
+	if err != sql.ErrNoRows {
+		return d, err
+	}
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM review_delegations WHERE id = $1)", id).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return nil, ErrDelegationEnded
+}
+
+// delegateFor returns the delegate who takes userID's place as a reviewer
+// under cq, or "" if userID has no active delegation or the delegate is not
+// eligible: the delegate must be active, not leaving, not in taken, not
+// already reviewing cq.PrID and senior when cq.SeniorOnly is set. Delegations
+// are not chained.
+func (s *Store) delegateFor(ctx context.Context, q querier, userID string, cq candidateQuery, taken []string) (string, error) {
+	var delegate string
+	err := q.QueryRowContext(ctx, `
+		SELECT u.id
+		FROM review_delegations d
+		JOIN users u ON d.delegate_id = u.id
+		WHERE d.user_id = $1
+		  AND `+activeDelegation+`
+		  AND u.is_active = true AND u.deleted_at IS NULL
+		  AND u.id <> ALL($2)
+		  AND u.id NOT IN (SELECT user_id FROM reviewers WHERE pull_request_id = $3)
+		  AND (NOT $4 OR u.is_senior)
+		  AND `+notLeaving+`
+		LIMIT 1
+	`, userID, taken, cq.PrID, cq.SeniorOnly).Scan(&delegate)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return delegate, err
+}
@@ -0,0 +1,239 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"pr-reviewer/internal/model"
+)
+
+const (
+	JobBulkDeactivate = "BULK_DEACTIVATE"
+	JobBulkReactivate = "BULK_REACTIVATE"
+
+	JobPending   = "PENDING"
+	JobRunning   = "RUNNING"
+	JobSucceeded = "SUCCEEDED"
+	JobFailed    = "FAILED"
+	JobCancelled = "CANCELLED"
+)
+
+// BulkDeactivatePayload is the input of a BULK_DEACTIVATE job.
+type BulkDeactivatePayload struct {
+	UserIDs []string `json:"user_ids"`
+}
+
+// BulkReactivatePayload is the input of a BULK_REACTIVATE job.
+type BulkReactivatePayload struct {
+	UserIDs   []string `json:"user_ids"`
+	Rebalance bool     `json:"rebalance"`
+}
+
+const jobColumns = `id, type, status, payload, result, COALESCE(error, ''), total, processed,
+	cancel_requested, created_at, started_at, finished_at`
+
+func scanJob(row interface{ Scan(...any) error }) (*model.Job, error) {
+	var job model.Job
+	var result []byte
+	err := row.Scan(&job.JobID, &job.Type, &job.Status, &job.Payload, &result, &job.Error,
+		&job.Total, &job.Processed, &job.CancelRequested, &job.CreatedAt, &job.StartedAt, &job.FinishedAt)
+	if err != nil {
+		return nil, err
+	}
+	if result != nil {
+		job.Result = result
+	}
+	return &job, nil
+}
+
+// SubmitBulkDeactivation queues a BULK_DEACTIVATE job. Duplicate ids are
+// dropped.
+func (s *Store) SubmitBulkDeactivation(ctx context.Context, userIDs []string) (*model.Job, error) {
+	ids := uniqueIDs(userIDs)
+	return s.createJob(ctx, JobBulkDeactivate, BulkDeactivatePayload{UserIDs: ids}, len(ids))
+}
+
+// SubmitBulkReactivation queues a BULK_REACTIVATE job. Duplicate ids are
+// dropped.
+func (s *Store) SubmitBulkReactivation(ctx context.Context, userIDs []string, rebalance bool) (*model.Job, error) {
+	ids := uniqueIDs(userIDs)
+	return s.createJob(ctx, JobBulkReactivate, BulkReactivatePayload{UserIDs: ids, Rebalance: rebalance}, len(ids))
+}
+
+func (s *Store) createJob(ctx context.Context, jobType string, payload any, total int) (*model.Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return scanJob(s.db.QueryRowContext(ctx,
+		"INSERT INTO jobs (type, payload, total) VALUES ($1, $2, $3) RETURNING "+jobColumns,
+		jobType, data, total,
+	))
+}
+
+func (s *Store) GetJob(ctx context.Context, id string) (*model.Job, error) {
+	job, err := scanJob(s.db.QueryRowContext(ctx, "SELECT "+jobColumns+" FROM jobs WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return job, err
+}
+
+// CancelJob cancels a pending job right away. A running job is only flagged
+// and stops after the chunk it is working on. Finished jobs give
+// ErrJobFinished.
+func (s *Store) CancelJob(ctx context.Context, id string) (*model.Job, error) {
+	job, err := scanJob(s.db.QueryRowContext(ctx, `
+		UPDATE jobs SET
+			status = CASE WHEN status = 'PENDING' THEN 'CANCELLED' ELSE status END,
+			finished_at = CASE WHEN status = 'PENDING' THEN CURRENT_TIMESTAMP ELSE finished_at END,
+			cancel_requested = true,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status IN ('PENDING', 'RUNNING')
+		RETURNING `+jobColumns, id))
+	if err != sql.ErrNoRows {
+		return job, err
+	}
+	if _, err := s.GetJob(ctx, id); err != nil {
+		return nil, err
+	}
+	return nil, ErrJobFinished
+}
+
+// ClaimJob marks the oldest pending job as running and returns it, or nil if
+// there is none.
+func (s *Store) ClaimJob(ctx context.Context) (*model.Job, error) {
+	job, err := scanJob(s.db.QueryRowContext(ctx, `
+		UPDATE jobs SET status = 'RUNNING', started_at = COALESCE(started_at, CURRENT_TIMESTAMP), updated_at = CURRENT_TIMESTAMP
+		WHERE id = (
+			SELECT id FROM jobs WHERE status = 'PENDING'
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+jobColumns))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return job, err
+}
+
+// RequeueRunningJobs puts jobs left RUNNING by a previous server process back
+// in the queue. They resume from their last saved progress. It must only be
+// called at startup, before any job is claimed.
+func (s *Store) RequeueRunningJobs(ctx context.Context) (int, error) {
+	res, err := s.db.ExecContext(ctx,
+		"UPDATE jobs SET status = 'PENDING', updated_at = CURRENT_TIMESTAMP WHERE status = 'RUNNING'",
+	)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// BulkReactivateResult is the result of a BULK_REACTIVATE job.
+type BulkReactivateResult struct {
+	Users []ReactivationReport `json:"users"`
+}
+
+// RunDeactivationChunk deactivates ids, one chunk of a BULK_DEACTIVATE job,
+// merges the chunk's report into result and saves it with processed as the
+// job's progress, all in one transaction: a chunk is either applied and
+// counted or neither. Reviews never go to anyone in jobUserIDs, the job's
+// users. It returns the chunk's report and whether the job has been asked to
+// stop. On error result may hold the failed chunk; the saved progress does
+// not.
+func (s *Store) RunDeactivationChunk(ctx context.Context, jobID string, ids, jobUserIDs []string, processed int, result *DeactivationReport) (*DeactivationReport, bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	chunk, err := s.deactivateUsers(ctx, tx, ids, jobUserIDs)
+	if err != nil {
+		return nil, false, err
+	}
+	result.Merge(chunk)
+	cancel, err := saveJobProgress(ctx, tx, jobID, processed, result)
+	if err != nil {
+		return nil, false, err
+	}
+	return chunk, cancel, tx.Commit()
+}
+
+// RunReactivationChunk reactivates ids, one chunk of a BULK_REACTIVATE job,
+// like RunDeactivationChunk.
+func (s *Store) RunReactivationChunk(ctx context.Context, jobID string, ids []string, rebalance bool, processed int, result *BulkReactivateResult) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	reports, err := s.reactivateUsers(ctx, tx, ids, rebalance)
+	if err != nil {
+		return false, err
+	}
+	result.Users = append(result.Users, reports...)
+	cancel, err := saveJobProgress(ctx, tx, jobID, processed, result)
+	if err != nil {
+		return false, err
+	}
+	return cancel, tx.Commit()
+}
+
+// SaveJobProgress records how many items of a running job are done and the
+// result so far. It reports whether the job has been asked to stop.
+func (s *Store) SaveJobProgress(ctx context.Context, id string, processed int, result any) (bool, error) {
+	return saveJobProgress(ctx, s.db, id, processed, result)
+}
+
+func saveJobProgress(ctx context.Context, q querier, id string, processed int, result any) (bool, error) {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return false, err
+	}
+	var cancel bool
+	err = q.QueryRowContext(ctx, `
+		UPDATE jobs SET processed = $2, result = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING cancel_requested
+	`, id, processed, data).Scan(&cancel)
+	if err == sql.ErrNoRows {
+		return false, ErrNotFound
+	}
+	return cancel, err
+}
+
+// FinishJob moves a job to a final status with its result and, for failed
+// jobs, the error message. A nil result keeps the result saved last.
+func (s *Store) FinishJob(ctx context.Context, id, status string, result any, errMsg string) error {
+	var data []byte
+	if result != nil {
+		var err error
+		if data, err = json.Marshal(result); err != nil {
+			return err
+		}
+	}
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE jobs SET status = $2, result = COALESCE($3, result), error = NULLIF($4, ''),
+			updated_at = CURRENT_TIMESTAMP, finished_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`, id, status, data, errMsg)
+	return err
+}
+
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	out := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out
+}
@@ -0,0 +1,176 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	NoCandidateNotify     = "NOTIFY"
+	NoCandidateAssignLead = "ASSIGN_LEAD"
+)
+
+// LeadAlert is a review for which no replacement could be found. The leads of
+// the PR's team are told about it.
+type LeadAlert struct {
+	TeamName      string   `json:"team_name"`
+	PullRequestID string   `json:"pull_request_id"`
+	ReviewerID    string   `json:"reviewer_id"`
+	Leads         []string `json:"leads"`
+}
+
+// SetTeamLeads replaces the leads of teamName. Leads need not be members.
+// While the team's policy sets leads_only_management the leads cannot be
+// cleared (ErrLeadsRequired), as that would lift the restriction.
+func (s *Store) SetTeamLeads(ctx context.Context, teamName string, userIDs []string) ([]string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockTeam(ctx, tx, teamName); err != nil {
+		return nil, err
+	}
+	if len(userIDs) == 0 {
+		var restricted bool
+		err := tx.QueryRowContext(ctx,
+			"SELECT EXISTS (SELECT 1 FROM team_policies WHERE team_name = $1 AND leads_only_management)", teamName,
+		).Scan(&restricted)
+		if err != nil {
+			return nil, err
+		}
+		if restricted {
+			return nil, ErrLeadsRequired
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM team_leads WHERE team_name = $1", teamName); err != nil {
+		return nil, err
+	}
+	for _, id := range userIDs {
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO team_leads (team_name, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING", teamName, id,
+		)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+				return nil, ErrUserNotFound
+			}
+			return nil, err
+		}
+	}
+
+	leads, err := teamLeads(ctx, tx, teamName)
+	if err != nil {
+		return nil, err
+	}
+	return leads, tx.Commit()
+}
+
+func teamLeads(ctx context.Context, q querier, teamName string) ([]string, error) {
+	return queryIDs(ctx, q, "SELECT user_id FROM team_leads WHERE team_name = $1 ORDER BY user_id", teamName)
+}
+
+// CanManageTeam reports whether userID may run management operations on
+// teamName. Only teams whose policy sets leads_only_management and that have
+// at least one lead are restricted.
+func (s *Store) CanManageTeam(ctx context.Context, teamName, userID string) (bool, error) {
+	var allowed bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT NOT COALESCE((SELECT leads_only_management FROM team_policies WHERE team_name = $1), false)
+		    OR NOT EXISTS (SELECT 1 FROM team_leads WHERE team_name = $1)
+		    OR EXISTS (SELECT 1 FROM team_leads WHERE team_name = $1 AND user_id = $2)
+	`, teamName, userID).Scan(&allowed)
+	return allowed, err
+}
+
+// RestrictedTeams returns the teams among teamNames and the teams of userIDs
+// that actorID may not manage, as CanManageTeam decides, sorted by name.
+func (s *Store) RestrictedTeams(ctx context.Context, actorID string, userIDs, teamNames []string) ([]string, error) {
+	return queryIDs(ctx, s.db, `
+		SELECT tp.team_name
+		FROM team_policies tp
+		WHERE tp.leads_only_management
+		  AND (tp.team_name = ANY($2)
+		       OR tp.team_name IN (SELECT team_name FROM team_memberships WHERE user_id = ANY($1)))
+		  AND EXISTS (SELECT 1 FROM team_leads l WHERE l.team_name = tp.team_name)
+		  AND NOT EXISTS (SELECT 1 FROM team_leads l WHERE l.team_name = tp.team_name AND l.user_id = $3)
+		ORDER BY tp.team_name
+	`, userIDs, teamNames, actorID)
+}
+
+// leadFallback returns an active lead of teamName to take a review nobody
+// else can take, if the team's no_candidate_action is ASSIGN_LEAD. It returns
+// "" when the policy says otherwise or no lead is eligible.
+func (s *Store) leadFallback(ctx context.Context, q querier, teamName, prID string, exclude []string) (string, error) {
+	var lead string
+	err := q.QueryRowContext(ctx, `
+		SELECT l.user_id
+		FROM team_leads l
+		JOIN users u ON l.user_id = u.id
+		JOIN team_policies tp ON tp.team_name = l.team_name
+		WHERE l.team_name = $1
+		  AND tp.no_candidate_action = 'ASSIGN_LEAD'
+		  AND u.is_active = true
+		  AND u.id <> ALL($2)
+		  AND u.id NOT IN (SELECT user_id FROM reviewers WHERE pull_request_id = $3)
+		  AND `+notLeaving+`
+		ORDER BY RANDOM()
+		LIMIT 1
+	`, teamName, exclude, prID).Scan(&lead)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return lead, err
+}
+
+// leadAlerts turns reviews without a replacement into alerts for the leads
+// of the PRs' teams.
+func (s *Store) leadAlerts(ctx context.Context, q querier, tasks []reviewAssignment) ([]LeadAlert, error) {
+	alerts := []LeadAlert{}
+	leadsByTeam := make(map[string][]string)
+	for _, task := range tasks {
+		leads, ok := leadsByTeam[task.TeamName]
+		if !ok {
+			var err error
+			leads, err = teamLeads(ctx, q, task.TeamName)
+			if err != nil {
+				return nil, err
+			}
+			leadsByTeam[task.TeamName] = leads
+		}
+		alerts = append(alerts, LeadAlert{
+			TeamName:      task.TeamName,
+			PullRequestID: task.PrID,
+			ReviewerID:    task.OldUser,
+			Leads:         leads,
+		})
+	}
+	return alerts, nil
+}
+
+// NoCandidateAlert builds the alert for a failed reassignment of reviewerID
+// on prID.
+func (s *Store) NoCandidateAlert(ctx context.Context, prID, reviewerID string) (*LeadAlert, error) {
+	task := reviewAssignment{PrID: prID, OldUser: reviewerID}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT p.author_id, COALESCE(p.team_name, a.team_name, '')
+		FROM pull_requests p
+		JOIN users a ON p.author_id = a.id
+		WHERE p.id = $1
+	`, prID).Scan(&task.AuthorID, &task.TeamName)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	alerts, err := s.leadAlerts(ctx, s.db, []reviewAssignment{task})
+	if err != nil {
+		return nil, err
+	}
+	return &alerts[0], nil
+}
@@ -0,0 +1,451 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	OrgReasonNotInChart      = "NOT_IN_CHART"
+	OrgReasonInactiveInChart = "INACTIVE_IN_CHART"
+)
+
+// OrgChart is the desired state of teams and users, usually exported from HR
+// data. Every user belongs to exactly one team in the chart, which becomes
+// their primary team.
+type OrgChart struct {
+	Teams []OrgTeam `json:"teams" yaml:"teams"`
+	Users []OrgUser `json:"users" yaml:"users"`
+}
+
+// OrgTeam lists a team explicitly, to give it a parent or to create it
+// without members. Teams referenced only by users are created without one.
+type OrgTeam struct {
+	Name       string `json:"team_name" yaml:"team_name"`
+	ParentTeam string `json:"parent_team,omitempty" yaml:"parent_team"`
+}
+
+type OrgUser struct {
+	UserID   string `json:"user_id" yaml:"user_id"`
+	Username string `json:"username" yaml:"username"`
+	TeamName string `json:"team_name" yaml:"team_name"`
+	IsActive *bool  `json:"is_active,omitempty" yaml:"is_active"` // defaults to true
+}
+
+func (u OrgUser) active() bool {
+	return u.IsActive == nil || *u.IsActive
+}
+
+type OrgParentChange struct {
+	TeamName string `json:"team_name"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+}
+
+type OrgRename struct {
+	UserID string `json:"user_id"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
+
+type OrgMove struct {
+	UserID   string `json:"user_id"`
+	FromTeam string `json:"from_team"`
+	ToTeam   string `json:"to_team"`
+}
+
+type OrgDeactivation struct {
+	UserID string `json:"user_id"`
+	Reason string `json:"reason"`
+}
+
+// OrgSyncPlan is the difference between the database and an org chart.
+// RemoveMemberships lists users missing from the chart who still belong to
+// a team; they leave all their teams.
+type OrgSyncPlan struct {
+	CreateTeams       []OrgTeam         `json:"create_teams"`
+	SetParents        []OrgParentChange `json:"set_parents"`
+	CreateUsers       []OrgUser         `json:"create_users"`
+	RenameUsers       []OrgRename       `json:"rename_users"`
+	MoveUsers         []OrgMove         `json:"move_users"`
+	Activate          []string          `json:"activate"`
+	Deactivate        []OrgDeactivation `json:"deactivate"`
+	RemoveMemberships []string          `json:"remove_memberships"`
+}
+
+// Scope returns the users whose memberships or status the plan changes and
+// the teams it changes, for authorization.
+func (p *OrgSyncPlan) Scope() (userIDs, teamNames []string) {
+	for _, u := range p.CreateUsers {
+		userIDs = append(userIDs, u.UserID)
+		teamNames = append(teamNames, u.TeamName)
+	}
+	for _, m := range p.MoveUsers {
+		userIDs = append(userIDs, m.UserID)
+		teamNames = append(teamNames, m.ToTeam)
+	}
+	userIDs = append(userIDs, p.Activate...)
+	for _, d := range p.Deactivate {
+		userIDs = append(userIDs, d.UserID)
+	}
+	userIDs = append(userIDs, p.RemoveMemberships...)
+	for _, c := range p.SetParents {
+		teamNames = append(teamNames, c.TeamName)
+	}
+	return userIDs, teamNames
+}
+
+type OrgSyncResult struct {
+	Applied       bool                `json:"applied"`
+	Plan          *OrgSyncPlan        `json:"plan"`
+	Reassignments map[string][]string `json:"reassignments,omitempty"`
+	Deactivation  *DeactivationReport `json:"deactivation,omitempty"`
+}
+
+type orgTeamState struct {
+	parent   string
+	archived bool
+}
+
+type orgUserState struct {
+	username string
+	team     string
+	active   bool
+	member   bool // belongs to at least one team
+}
+
+// SyncOrgChart reconciles teams and users with chart. Without apply it only
+// returns the plan. With apply the plan is carried out in one transaction:
+// missing teams are created, parents set, users created, renamed, moved
+// (their reviews on the old team's OPEN PRs are reassigned) and activated,
+// and users that are inactive in or missing from the chart are deactivated
+// with their open reviews reassigned. Users missing from the chart also
+// leave all their teams. Existing memberships keep their is_active flag.
+// Teams are never removed.
+func (s *Store) SyncOrgChart(ctx context.Context, chart *OrgChart, apply bool) (*OrgSyncResult, error) {
+	if err := chart.validate(); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	lock := ""
+	if apply {
+		lock = " FOR UPDATE"
+	}
+	teams, err := loadOrgTeams(ctx, tx, lock)
+	if err != nil {
+		return nil, err
+	}
+	users, order, err := loadOrgUsers(ctx, tx, lock)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := chart.plan(teams, users, order)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &OrgSyncResult{Plan: plan}
+	if !apply {
+		return result, nil
+	}
+
+	for _, team := range plan.CreateTeams {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO teams (name) VALUES ($1)", team.Name); err != nil {
+			return nil, err
+		}
+	}
+	for _, team := range plan.CreateTeams {
+		if team.ParentTeam == "" {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE teams SET parent_team = $2 WHERE name = $1", team.Name, team.ParentTeam); err != nil {
+			return nil, err
+		}
+	}
+	for _, change := range plan.SetParents {
+		_, err := tx.ExecContext(ctx, "UPDATE teams SET parent_team = NULLIF($2, '') WHERE name = $1", change.TeamName, change.To)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, u := range plan.CreateUsers {
+		// Deleted users are restored under the same id.
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO users (id, username, team_name, is_active) VALUES ($1, $2, $3, $4)
+			ON CONFLICT (id) DO UPDATE SET
+				username = EXCLUDED.username,
+				team_name = EXCLUDED.team_name,
+				is_active = EXCLUDED.is_active,
+				deleted_at = NULL
+		`, u.UserID, u.Username, u.TeamName, u.active())
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO team_memberships (team_name, user_id) VALUES ($1, $2)
+			ON CONFLICT (team_name, user_id) DO NOTHING
+		`, u.TeamName, u.UserID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, r := range plan.RenameUsers {
+		if _, err := tx.ExecContext(ctx, "UPDATE users SET username = $2 WHERE id = $1", r.UserID, r.To); err != nil {
+			return nil, err
+		}
+	}
+
+	result.Reassignments = map[string][]string{}
+	for _, m := range plan.MoveUsers {
+		transfer, err := s.transferUser(ctx, tx, m.UserID, m.FromTeam, m.ToTeam, TransferReassignReviews, false)
+		if err != nil {
+			return nil, err
+		}
+		for prID, ids := range transfer.Reassignments {
+			result.Reassignments[prID] = append(result.Reassignments[prID], ids...)
+		}
+	}
+
+	if len(plan.Activate) > 0 {
+		if _, err := tx.ExecContext(ctx, "UPDATE users SET is_active = true WHERE id = ANY($1)", plan.Activate); err != nil {
+			return nil, err
+		}
+	}
+	if len(plan.Deactivate) > 0 {
+		ids := make([]string, len(plan.Deactivate))
+		for i, d := range plan.Deactivate {
+			ids[i] = d.UserID
+		}
+		if result.Deactivation, err = s.deactivateUsers(ctx, tx, ids, nil); err != nil {
+			return nil, err
+		}
+	}
+	if len(plan.RemoveMemberships) > 0 {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM team_memberships WHERE user_id = ANY($1)", plan.RemoveMemberships); err != nil {
+			return nil, err
+		}
+		if _, err := tx.ExecContext(ctx, "UPDATE users SET team_name = NULL WHERE id = ANY($1)", plan.RemoveMemberships); err != nil {
+			return nil, err
+		}
+	}
+
+	result.Applied = true
+	return result, tx.Commit()
+}
+
+func loadOrgTeams(ctx context.Context, tx *sql.Tx, lock string) (map[string]orgTeamState, error) {
+	rows, err := tx.QueryContext(ctx, "SELECT name, COALESCE(parent_team, ''), archived_at FROM teams"+lock)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams := make(map[string]orgTeamState)
+	for rows.Next() {
+		var name string
+		var t orgTeamState
+		var archivedAt *time.Time
+		if err := rows.Scan(&name, &t.parent, &archivedAt); err != nil {
+			return nil, err
+		}
+		t.archived = archivedAt != nil
+		teams[name] = t
+	}
+	return teams, rows.Err()
+}
+
+func loadOrgUsers(ctx context.Context, tx *sql.Tx, lock string) (map[string]orgUserState, []string, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, username, COALESCE(team_name, ''), is_active,
+		       team_name IS NOT NULL OR EXISTS (SELECT 1 FROM team_memberships m WHERE m.user_id = users.id)
+		FROM users
+		WHERE deleted_at IS NULL
+		ORDER BY id`+lock)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	users := make(map[string]orgUserState)
+	var order []string
+	for rows.Next() {
+		var id string
+		var u orgUserState
+		if err := rows.Scan(&id, &u.username, &u.team, &u.active, &u.member); err != nil {
+			return nil, nil, err
+		}
+		users[id] = u
+		order = append(order, id)
+	}
+	return users, order, rows.Err()
+}
+
+// validate checks the chart on its own, before it is compared with the
+// database.
+func (chart *OrgChart) validate() error {
+	var problems []string
+	addf := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	seenTeams := make(map[string]bool)
+	for i, team := range chart.Teams {
+		if team.Name == "" {
+			addf("team %d has no team_name", i+1)
+			continue
+		}
+		if seenTeams[team.Name] {
+			addf("duplicate team %q", team.Name)
+		}
+		seenTeams[team.Name] = true
+		if team.ParentTeam == team.Name {
+			addf("team %q cannot be its own parent", team.Name)
+		}
+	}
+
+	if len(chart.Users) == 0 {
+		addf("the chart lists no users")
+	}
+	seenUsers := make(map[string]bool)
+	for i, u := range chart.Users {
+		if u.UserID == "" || u.Username == "" || u.TeamName == "" {
+			addf("user %d needs user_id, username and team_name", i+1)
+			continue
+		}
+		if seenUsers[u.UserID] {
+			addf("duplicate user %q", u.UserID)
+		}
+		seenUsers[u.UserID] = true
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidOrgChart, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// plan compares the chart with the current teams and the users that are not
+// deleted, listed in order.
+func (chart *OrgChart) plan(teams map[string]orgTeamState, users map[string]orgUserState, order []string) (*OrgSyncPlan, error) {
+	plan := &OrgSyncPlan{
+		CreateTeams:       []OrgTeam{},
+		SetParents:        []OrgParentChange{},
+		CreateUsers:       []OrgUser{},
+		RenameUsers:       []OrgRename{},
+		MoveUsers:         []OrgMove{},
+		Activate:          []string{},
+		Deactivate:        []OrgDeactivation{},
+		RemoveMemberships: []string{},
+	}
+	var problems []string
+
+	// Teams named in the chart, explicitly or through their users, in the
+	// order they first appear.
+	parents := make(map[string]string)
+	listed := make(map[string]bool)
+	var names []string
+	addTeam := func(name string) {
+		if !listed[name] {
+			listed[name] = true
+			names = append(names, name)
+		}
+	}
+	for _, team := range chart.Teams {
+		addTeam(team.Name)
+		parents[team.Name] = team.ParentTeam
+	}
+	for _, u := range chart.Users {
+		addTeam(u.TeamName)
+	}
+
+	for _, name := range names {
+		current, exists := teams[name]
+		parent, hasParent := parents[name]
+		switch {
+		case !exists:
+			plan.CreateTeams = append(plan.CreateTeams, OrgTeam{Name: name, ParentTeam: parent})
+		case current.archived:
+			problems = append(problems, fmt.Sprintf("team %q is archived", name))
+		case hasParent && parent != current.parent:
+			plan.SetParents = append(plan.SetParents, OrgParentChange{TeamName: name, From: current.parent, To: parent})
+		}
+		if parent != "" && !listed[parent] {
+			if p, ok := teams[parent]; !ok {
+				problems = append(problems, fmt.Sprintf("team %q has unknown parent team %q", name, parent))
+			} else if p.archived {
+				problems = append(problems, fmt.Sprintf("parent team %q of %q is archived", parent, name))
+			}
+		}
+	}
+
+	// The hierarchy after the sync must not contain a cycle.
+	final := make(map[string]string)
+	for name, t := range teams {
+		final[name] = t.parent
+	}
+	for _, name := range names {
+		if parent, ok := parents[name]; ok {
+			final[name] = parent
+		} else if _, exists := teams[name]; !exists {
+			final[name] = ""
+		}
+	}
+	for _, name := range names {
+		parent := final[name]
+		for steps := 0; parent != ""; steps++ {
+			if parent == name || steps > len(final) {
+				problems = append(problems, fmt.Sprintf("the ancestors of team %q would form a cycle", name))
+				break
+			}
+			parent = final[parent]
+		}
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidOrgChart, strings.Join(problems, "; "))
+	}
+
+	inChart := make(map[string]bool)
+	for _, u := range chart.Users {
+		inChart[u.UserID] = true
+		current, exists := users[u.UserID]
+		if !exists {
+			plan.CreateUsers = append(plan.CreateUsers, u)
+			continue
+		}
+		if current.username != u.Username {
+			plan.RenameUsers = append(plan.RenameUsers, OrgRename{UserID: u.UserID, From: current.username, To: u.Username})
+		}
+		if current.team != u.TeamName {
+			plan.MoveUsers = append(plan.MoveUsers, OrgMove{UserID: u.UserID, FromTeam: current.team, ToTeam: u.TeamName})
+		}
+		switch {
+		case u.active() && !current.active:
+			plan.Activate = append(plan.Activate, u.UserID)
+		case !u.active() && current.active:
+			plan.Deactivate = append(plan.Deactivate, OrgDeactivation{UserID: u.UserID, Reason: OrgReasonInactiveInChart})
+		}
+	}
+	for _, id := range order {
+		if inChart[id] {
+			continue
+		}
+		if users[id].active {
+			plan.Deactivate = append(plan.Deactivate, OrgDeactivation{UserID: id, Reason: OrgReasonNotInChart})
+		}
+		if users[id].member {
+			plan.RemoveMemberships = append(plan.RemoveMemberships, id)
+		}
+	}
+	return plan, nil
+}
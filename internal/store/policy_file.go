@@ -0,0 +1,169 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	StrategyRandom      = "random"
+	StrategyLeastLoaded = "least_loaded"
+)
+
+// PolicyFile holds the assignment policies loaded from the file named by
+// POLICY_FILE. Team entries override the defaults field by field.
+type PolicyFile struct {
+	Path     string                `json:"path" yaml:"-"`
+	LoadedAt time.Time             `json:"loaded_at" yaml:"-"`
+	Defaults FilePolicy            `json:"defaults" yaml:"defaults"`
+	Teams    map[string]FilePolicy `json:"teams" yaml:"teams"`
+}
+
+type FilePolicy struct {
+	// ReviewerCount replaces the default of 2 reviewers for PRs without a
+	// matching size rule.
+	ReviewerCount *int `json:"reviewer_count,omitempty" yaml:"reviewer_count"`
+	// Strategy is random or least_loaded (fewest reviews on OPEN PRs first).
+	Strategy string `json:"strategy,omitempty" yaml:"strategy"`
+	// MaxOpenReviews skips members who already review this many OPEN PRs.
+	// 0 means no cap.
+	MaxOpenReviews *int `json:"max_open_reviews,omitempty" yaml:"max_open_reviews"`
+	// FallbackTeam is searched, with its ancestors, once the team and its
+	// ancestors are out of candidates.
+	FallbackTeam string `json:"fallback_team,omitempty" yaml:"fallback_team"`
+}
+
+// assignmentPolicy is the effective file policy of one team.
+type assignmentPolicy struct {
+	reviewerCount  int
+	strategy       string
+	maxOpenReviews int
+	fallbackTeam   string
+}
+
+var builtinAssignmentPolicy = assignmentPolicy{reviewerCount: defaultReviewerCount, strategy: StrategyRandom}
+
+// Validate reports every problem in the file at once, each prefixed with
+// where it was found.
+func (f *PolicyFile) Validate() error {
+	var problems []string
+	check := func(where string, p FilePolicy) {
+		if p.ReviewerCount != nil && *p.ReviewerCount < 0 {
+			problems = append(problems, where+".reviewer_count: must not be negative")
+		}
+		if p.Strategy != "" && p.Strategy != StrategyRandom && p.Strategy != StrategyLeastLoaded {
+			problems = append(problems, fmt.Sprintf("%s.strategy: %q is not one of %s, %s", where, p.Strategy, StrategyRandom, StrategyLeastLoaded))
+		}
+		if p.MaxOpenReviews != nil && *p.MaxOpenReviews < 0 {
+			problems = append(problems, where+".max_open_reviews: must not be negative")
+		}
+	}
+
+	check("defaults", f.Defaults)
+	if f.Defaults.FallbackTeam != "" {
+		problems = append(problems, "defaults.fallback_team: fallback teams can only be set per team")
+	}
+	names := make([]string, 0, len(f.Teams))
+	for name := range f.Teams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := f.Teams[name]
+		check("teams."+name, p)
+		if p.FallbackTeam == name {
+			problems = append(problems, fmt.Sprintf("teams.%s.fallback_team: a team cannot fall back to itself", name))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidPolicy, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (f *PolicyFile) forTeam(teamName string) assignmentPolicy {
+	p := builtinAssignmentPolicy
+	for _, fp := range []FilePolicy{f.Defaults, f.Teams[teamName]} {
+		if fp.ReviewerCount != nil {
+			p.reviewerCount = *fp.ReviewerCount
+		}
+		if fp.Strategy != "" {
+			p.strategy = fp.Strategy
+		}
+		if fp.MaxOpenReviews != nil {
+			p.maxOpenReviews = *fp.MaxOpenReviews
+		}
+	}
+	p.fallbackTeam = f.Teams[teamName].FallbackTeam
+	return p
+}
+
+// CheckPolicyFile reports fallback teams that do not exist or are archived
+// as ErrInvalidPolicy, each prefixed with where it was found.
+func (s *Store) CheckPolicyFile(ctx context.Context, f *PolicyFile) error {
+	names := make([]string, 0, len(f.Teams))
+	fallbacks := []string{}
+	for name, p := range f.Teams {
+		if p.FallbackTeam != "" {
+			names = append(names, name)
+			fallbacks = append(fallbacks, p.FallbackTeam)
+		}
+	}
+	if len(fallbacks) == 0 {
+		return nil
+	}
+	known, err := queryIDs(ctx, s.db, "SELECT name FROM teams WHERE name = ANY($1) AND archived_at IS NULL", fallbacks)
+	if err != nil {
+		return err
+	}
+	exists := make(map[string]bool, len(known))
+	for _, name := range known {
+		exists[name] = true
+	}
+
+	sort.Strings(names)
+	var problems []string
+	for _, name := range names {
+		if fallback := f.Teams[name].FallbackTeam; !exists[fallback] {
+			problems = append(problems, fmt.Sprintf("teams.%s.fallback_team: team %q does not exist or is archived", name, fallback))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidPolicy, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// ApplyPolicyFile checks f against the teams in the database and, if it
+// passes, puts it in use. On error the policies in use are kept.
+func (s *Store) ApplyPolicyFile(ctx context.Context, f *PolicyFile) error {
+	if err := s.CheckPolicyFile(ctx, f); err != nil {
+		return err
+	}
+	s.SetPolicyFile(f)
+	return nil
+}
+
+// SetPolicyFile replaces the file policies used by new assignments without
+// checking them against the database. A nil file restores the built-in
+// behaviour.
+func (s *Store) SetPolicyFile(f *PolicyFile) {
+	s.policyFile.Store(f)
+}
+
+// PolicyFile returns the file policies in use, or nil if there are none.
+func (s *Store) PolicyFile() *PolicyFile {
+	return s.policyFile.Load()
+}
+
+func (s *Store) assignmentPolicy(teamName string) assignmentPolicy {
+	f := s.policyFile.Load()
+	if f == nil {
+		return builtinAssignmentPolicy
+	}
+	return f.forTeam(teamName)
+}
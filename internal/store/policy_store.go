@@ -0,0 +1,213 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pr-reviewer/internal/model"
+)
+
+const defaultReminderThresholdHours = 24
+
+func (s *Store) GetTeamPolicy(ctx context.Context, teamName string) (*model.TeamPolicy, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM teams WHERE name = $1)", teamName).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	threshold := defaultReminderThresholdHours
+	dayStart, dayEnd := defaultWorkDayStart, defaultWorkDayEnd
+	policy := &model.TeamPolicy{
+		TeamName:               teamName,
+		SLAAction:              SLAActionReassign,
+		ReminderThresholdHours: &threshold,
+		NoCandidateAction:      NoCandidateNotify,
+		Timezone:               defaultTimezone,
+		WorkDayStart:           &dayStart,
+		WorkDayEnd:             &dayEnd,
+	}
+	var slaHours, reminderInterval sql.NullInt64
+	err = s.db.QueryRowContext(ctx, `
+		SELECT review_sla_hours, sla_action, reminder_interval_minutes, reminder_threshold_hours,
+		       no_candidate_action, leads_only_management, timezone, work_day_start, work_day_end
+		FROM team_policies WHERE team_name = $1
+	`, teamName).Scan(&slaHours, &policy.SLAAction, &reminderInterval, &threshold,
+		&policy.NoCandidateAction, &policy.LeadsOnlyManagement, &policy.Timezone, &dayStart, &dayEnd)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if slaHours.Valid {
+		hours := int(slaHours.Int64)
+		policy.ReviewSLAHours = &hours
+	}
+	if reminderInterval.Valid {
+		minutes := int(reminderInterval.Int64)
+		policy.ReminderIntervalMinutes = &minutes
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT size, reviewer_count, senior_required FROM team_size_rules
+		WHERE team_name = $1
+		ORDER BY array_position(ARRAY['XS', 'S', 'M', 'L', 'XL']::varchar[], size)
+	`, teamName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policy.SizeRules = []model.SizeRule{}
+	for rows.Next() {
+		var rule model.SizeRule
+		if err := rows.Scan(&rule.Size, &rule.ReviewerCount, &rule.SeniorRequired); err != nil {
+			return nil, err
+		}
+		policy.SizeRules = append(policy.SizeRules, rule)
+	}
+	return policy, rows.Err()
+}
+
+// SetTeamPolicy replaces the policy of an existing team. Omitted fields fall
+// back to their defaults.
+func (s *Store) SetTeamPolicy(ctx context.Context, policy *model.TeamPolicy) error {
+	applyPolicyDefaults(policy)
+	if err := validatePolicy(policy); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := writeTeamPolicy(ctx, tx, policy); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func applyPolicyDefaults(policy *model.TeamPolicy) {
+	if policy.SLAAction == "" {
+		policy.SLAAction = SLAActionReassign
+	}
+	if policy.ReminderThresholdHours == nil {
+		threshold := defaultReminderThresholdHours
+		policy.ReminderThresholdHours = &threshold
+	}
+	if policy.Timezone == "" {
+		policy.Timezone = defaultTimezone
+	}
+	if policy.WorkDayStart == nil {
+		dayStart := defaultWorkDayStart
+		policy.WorkDayStart = &dayStart
+	}
+	if policy.WorkDayEnd == nil {
+		dayEnd := defaultWorkDayEnd
+		policy.WorkDayEnd = &dayEnd
+	}
+	if policy.SizeRules == nil {
+		policy.SizeRules = []model.SizeRule{}
+	}
+	if policy.NoCandidateAction == "" {
+		policy.NoCandidateAction = NoCandidateNotify
+	}
+}
+
+// writeTeamPolicy stores a validated policy and its size rules, replacing the
+// previous ones.
+func writeTeamPolicy(ctx context.Context, q querier, policy *model.TeamPolicy) error {
+	query := `
+		INSERT INTO team_policies (team_name, review_sla_hours, sla_action, reminder_interval_minutes, reminder_threshold_hours,
+		                           no_candidate_action, leads_only_management, timezone, work_day_start, work_day_end)
+		SELECT name, $2, $3, $4, $5, $6, $7, $8, $9, $10 FROM teams WHERE name = $1
+		ON CONFLICT (team_name) DO UPDATE SET
+			review_sla_hours = EXCLUDED.review_sla_hours,
+			sla_action = EXCLUDED.sla_action,
+			reminder_interval_minutes = EXCLUDED.reminder_interval_minutes,
+			reminder_threshold_hours = EXCLUDED.reminder_threshold_hours,
+			no_candidate_action = EXCLUDED.no_candidate_action,
+			leads_only_management = EXCLUDED.leads_only_management,
+			timezone = EXCLUDED.timezone,
+			work_day_start = EXCLUDED.work_day_start,
+			work_day_end = EXCLUDED.work_day_end
+	`
+	res, err := q.ExecContext(ctx, query,
+		policy.TeamName, policy.ReviewSLAHours, policy.SLAAction,
+		policy.ReminderIntervalMinutes, policy.ReminderThresholdHours,
+		policy.NoCandidateAction, policy.LeadsOnlyManagement, policy.Timezone,
+		policy.WorkDayStart, policy.WorkDayEnd,
+	)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+
+	_, err = q.ExecContext(ctx, "DELETE FROM team_size_rules WHERE team_name = $1", policy.TeamName)
+	if err != nil {
+		return err
+	}
+	for _, rule := range policy.SizeRules {
+		_, err := q.ExecContext(ctx,
+			"INSERT INTO team_size_rules (team_name, size, reviewer_count, senior_required) VALUES ($1, $2, $3, $4)",
+			policy.TeamName, rule.Size, rule.ReviewerCount, rule.SeniorRequired,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validatePolicy(policy *model.TeamPolicy) error {
+	if policy.SLAAction != SLAActionReassign && policy.SLAAction != SLAActionAddBackup {
+		return fmt.Errorf("%w: sla_action must be one of %s, %s", ErrInvalidPolicy, SLAActionReassign, SLAActionAddBackup)
+	}
+	if policy.NoCandidateAction != NoCandidateNotify && policy.NoCandidateAction != NoCandidateAssignLead {
+		return fmt.Errorf("%w: no_candidate_action must be one of %s, %s", ErrInvalidPolicy, NoCandidateNotify, NoCandidateAssignLead)
+	}
+	if policy.ReviewSLAHours != nil && *policy.ReviewSLAHours <= 0 {
+		return fmt.Errorf("%w: review_sla_hours must be positive", ErrInvalidPolicy)
+	}
+	if policy.ReminderIntervalMinutes != nil && *policy.ReminderIntervalMinutes <= 0 {
+		return fmt.Errorf("%w: reminder_interval_minutes must be positive", ErrInvalidPolicy)
+	}
+	if *policy.ReminderThresholdHours < 0 {
+		return fmt.Errorf("%w: reminder_threshold_hours must not be negative", ErrInvalidPolicy)
+	}
+	if _, err := time.LoadLocation(policy.Timezone); err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", ErrInvalidPolicy, policy.Timezone)
+	}
+	if *policy.WorkDayStart < 0 || *policy.WorkDayEnd > 24 || *policy.WorkDayStart >= *policy.WorkDayEnd {
+		return fmt.Errorf("%w: work_day_start and work_day_end must satisfy 0 <= start < end <= 24", ErrInvalidPolicy)
+	}
+
+	seen := make(map[string]bool)
+	for _, rule := range policy.SizeRules {
+		if !validSize(rule.Size) {
+			return fmt.Errorf("%w: unknown size %q, expected one of %v", ErrInvalidPolicy, rule.Size, PRSizes)
+		}
+		if seen[rule.Size] {
+			return fmt.Errorf("%w: duplicate rule for size %s", ErrInvalidPolicy, rule.Size)
+		}
+		seen[rule.Size] = true
+		if rule.ReviewerCount < 0 {
+			return fmt.Errorf("%w: reviewer_count must not be negative", ErrInvalidPolicy)
+		}
+		if rule.SeniorRequired && rule.ReviewerCount == 0 {
+			return fmt.Errorf("%w: size %s requires a senior but has no reviewers", ErrInvalidPolicy, rule.Size)
+		}
+	}
+	return nil
+}
@@ -4,6 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgconn"
 	"pr-reviewer/internal/model"
@@ -16,8 +19,274 @@ func (s *Store) CreatePullRequest(ctx context.Context, pr *model.PullRequest) er
 	}
 	defer tx.Rollback()
 
+	if err := s.createPullRequest(ctx, tx, pr); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CreatePullRequests creates the PRs in a single transaction. The authors,
+// target teams, parents and ids of the whole batch are checked with one query
+// each and the PRs passing the checks are inserted with one statement; a PR
+// failing a check is left out and the others are still created. The returned
+// slice holds the error of every PR, nil for the created ones. A PR may depend
+// on one created earlier in the same batch.
+func (s *Store) CreatePullRequests(ctx context.Context, prs []*model.PullRequest) ([]error, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	errs, teams, err := checkPullRequests(ctx, tx, prs)
+	if err != nil {
+		return nil, err
+	}
+	var batch []*model.PullRequest
+	var batchTeams []string
+	for i, pr := range prs {
+		if errs[i] == nil {
+			pr.Size = classifySize(pr.LinesAdded, pr.LinesRemoved, pr.ChangedFiles)
+			batch = append(batch, pr)
+			batchTeams = append(batchTeams, teams[i])
+		}
+	}
+	if len(batch) == 0 {
+		return errs, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT batch_insert"); err != nil {
+		return nil, err
+	}
+	inserted, err := insertPullRequests(ctx, tx, batch, batchTeams)
+	if err != nil {
+		// Something the checks cannot see, such as an author deleted since,
+		// fails the whole statement. Fall back to one savepoint per PR so
+		// only the offending PR is left out.
+		if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT batch_insert"); err != nil {
+			return nil, err
+		}
+		return s.createEachPullRequest(ctx, tx, prs, errs)
+	}
+
+	var distinct []string
+	seen := make(map[string]bool)
+	for _, team := range batchTeams {
+		if !seen[team] {
+			seen[team] = true
+			distinct = append(distinct, team)
+		}
+	}
+	rules, err := sizeRules(ctx, tx, distinct)
+	if err != nil {
+		return nil, err
+	}
+	deadlines, err := s.reviewDeadlines(ctx, tx, distinct, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	lineages := make(map[string][]string)
+
+	for i, pr := range prs {
+		if errs[i] != nil {
+			continue
+		}
+		// The id was taken by a concurrent request after the checks.
+		if !inserted[pr.ID] {
+			errs[i] = ErrPRExists
+			continue
+		}
+		team := teams[i]
+		count, seniorRequired := s.assignmentPolicy(team).reviewerCount, false
+		if rule, ok := rules[sizeRuleKey{team, pr.Size}]; ok {
+			count, seniorRequired = rule.Count, rule.SeniorRequired
+		}
+		lineage, ok := lineages[team]
+		if !ok {
+			if lineage, err = s.candidateLineage(ctx, tx, team); err != nil {
+				return nil, err
+			}
+			lineages[team] = lineage
+		}
+		if err := s.assignNewPullRequest(ctx, tx, pr, team, count, seniorRequired, lineage, deadlines[team]); err != nil {
+			return nil, err
+		}
+	}
+	return errs, tx.Commit()
+}
+
+// createEachPullRequest creates the PRs whose errs entry is nil one at a time,
+// each in its own savepoint, and commits tx.
+func (s *Store) createEachPullRequest(ctx context.Context, tx *sql.Tx, prs []*model.PullRequest, errs []error) ([]error, error) {
+	for i, pr := range prs {
+		if errs[i] != nil {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT batch_item"); err != nil {
+			return nil, err
+		}
+		if errs[i] = s.createPullRequest(ctx, tx, pr); errs[i] != nil {
+			if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT batch_item"); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT batch_item"); err != nil {
+			return nil, err
+		}
+	}
+	return errs, tx.Commit()
+}
+
+// checkPullRequests runs the checks of createPullRequest on a whole batch with
+// one query per kind of check. It returns the error of every PR and the team
+// each PR goes to. A PR may depend on an earlier PR of the batch that passes
+// the checks, and an id may only appear once in the batch.
+func checkPullRequests(ctx context.Context, q querier, prs []*model.PullRequest) ([]error, []string, error) {
+	authorIDs := []string{}
+	targetTeams := []string{}
+	parentIDs := []string{}
+	prIDs := []string{}
+	for _, pr := range prs {
+		authorIDs = append(authorIDs, pr.AuthorID)
+		prIDs = append(prIDs, pr.ID)
+		if pr.TargetTeam != "" {
+			targetTeams = append(targetTeams, pr.TargetTeam)
+		}
+		if pr.DependsOn != "" {
+			parentIDs = append(parentIDs, pr.DependsOn)
+		}
+	}
+
+	authorTeams := make(map[string]string)
+	rows, err := q.QueryContext(ctx, "SELECT id, COALESCE(team_name, '') FROM users WHERE id = ANY($1)", authorIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	for rows.Next() {
+		var id, teamName string
+		if err := rows.Scan(&id, &teamName); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		authorTeams[id] = teamName
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	archivedTeams := make(map[string]bool)
+	rows, err = q.QueryContext(ctx, "SELECT name, archived_at IS NOT NULL FROM teams WHERE name = ANY($1)", targetTeams)
+	if err != nil {
+		return nil, nil, err
+	}
+	for rows.Next() {
+		var name string
+		var archived bool
+		if err := rows.Scan(&name, &archived); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		archivedTeams[name] = archived
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	parents, err := queryIDs(ctx, q, "SELECT id FROM pull_requests WHERE id = ANY($1)", parentIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Archived PRs keep their ids, so an id can never be reused.
+	taken, err := queryIDs(ctx, q, `
+		SELECT id FROM pull_requests WHERE id = ANY($1)
+		UNION
+		SELECT id FROM pull_requests_archive WHERE id = ANY($1)
+	`, prIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+	existing := make(map[string]bool)
+	for _, id := range parents {
+		existing[id] = true
+	}
+	used := make(map[string]bool)
+	for _, id := range taken {
+		used[id] = true
+	}
+
+	errs := make([]error, len(prs))
+	teams := make([]string, len(prs))
+	for i, pr := range prs {
+		teamName, found := authorTeams[pr.AuthorID]
+		if !found {
+			errs[i] = ErrNotFound
+			continue
+		}
+		// An explicit target team overrides the author's team.
+		if pr.TargetTeam != "" {
+			archived, ok := archivedTeams[pr.TargetTeam]
+			if !ok {
+				errs[i] = ErrTeamNotFound
+				continue
+			}
+			if archived {
+				errs[i] = ErrTeamArchived
+				continue
+			}
+			teamName = pr.TargetTeam
+		}
+		if pr.DependsOn != "" && !existing[pr.DependsOn] {
+			errs[i] = ErrParentNotFound
+			continue
+		}
+		if used[pr.ID] {
+			errs[i] = ErrPRExists
+			continue
+		}
+		teams[i] = teamName
+		existing[pr.ID] = true
+		used[pr.ID] = true
+	}
+	return errs, teams, nil
+}
+
+// insertPullRequests inserts prs as OPEN with one statement, each going to the
+// team at the same index of teams. It returns the ids inserted; an id taken
+// by a concurrent request is skipped.
+func insertPullRequests(ctx context.Context, q querier, prs []*model.PullRequest, teams []string) (map[string]bool, error) {
+	var query strings.Builder
+	query.WriteString(`
+		INSERT INTO pull_requests (id, name, author_id, status, lines_added, lines_removed, changed_files, size, parent_id, team_name)
+		VALUES `)
+	args := make([]any, 0, 9*len(prs))
+	for i, pr := range prs {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		n := len(args)
+		fmt.Fprintf(&query, "($%d, $%d, $%d, 'OPEN', $%d, $%d, $%d, NULLIF($%d, ''), NULLIF($%d, ''), NULLIF($%d, ''))",
+			n+1, n+2, n+3, n+4, n+5, n+6, n+7, n+8, n+9)
+		args = append(args, pr.ID, pr.Name, pr.AuthorID, pr.LinesAdded, pr.LinesRemoved, pr.ChangedFiles, pr.Size, pr.DependsOn, teams[i])
+	}
+	query.WriteString(" ON CONFLICT (id) DO NOTHING RETURNING id")
+
+	ids, err := queryIDs(ctx, q, query.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	inserted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		inserted[id] = true
+	}
+	return inserted, nil
+}
+
+func (s *Store) createPullRequest(ctx context.Context, tx *sql.Tx, pr *model.PullRequest) error {
 	var teamName string
-	err = tx.QueryRowContext(ctx, "SELECT team_name FROM users WHERE id = $1", pr.AuthorID).Scan(&teamName)
+	err := tx.QueryRowContext(ctx, "SELECT COALESCE(team_name, '') FROM users WHERE id = $1", pr.AuthorID).Scan(&teamName)
 	if err == sql.ErrNoRows {
 		return ErrNotFound 
 	}
@@ -25,10 +294,48 @@ func (s *Store) CreatePullRequest(ctx context.Context, pr *model.PullRequest) er
 		return err
 	}
 
-	_, err = tx.ExecContext(ctx, 
-		"INSERT INTO pull_requests (id, name, author_id, status) VALUES ($1, $2, $3, 'OPEN')",
-		pr.ID, pr.Name, pr.AuthorID,
-	)
+	// An explicit target team overrides the author's team.
+	if pr.TargetTeam != "" {
+		var archivedAt sql.NullTime
+		err = tx.QueryRowContext(ctx, "SELECT archived_at FROM teams WHERE name = $1", pr.TargetTeam).Scan(&archivedAt)
+		if err == sql.ErrNoRows {
+			return ErrTeamNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if archivedAt.Valid {
+			return ErrTeamArchived
+		}
+		teamName = pr.TargetTeam
+	}
+
+	if pr.DependsOn != "" {
+		var parentExists bool
+		err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM pull_requests WHERE id = $1)", pr.DependsOn).Scan(&parentExists)
+		if err != nil {
+			return err
+		}
+		if !parentExists {
+			return ErrParentNotFound
+		}
+	}
+
+	// Archived PRs keep their ids, so an id can never be reused.
+	var archived bool
+	err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM pull_requests_archive WHERE id = $1)", pr.ID).Scan(&archived)
+	if err != nil {
+		return err
+	}
+	if archived {
+		return ErrPRExists
+	}
+
+	pr.Size = classifySize(pr.LinesAdded, pr.LinesRemoved, pr.ChangedFiles)
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO pull_requests (id, name, author_id, status, lines_added, lines_removed, changed_files, size, parent_id, team_name)
+		VALUES ($1, $2, $3, 'OPEN', $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), NULLIF($9, ''))
+	`, pr.ID, pr.Name, pr.AuthorID, pr.LinesAdded, pr.LinesRemoved, pr.ChangedFiles, pr.Size, pr.DependsOn, teamName)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
@@ -37,56 +344,130 @@ func (s *Store) CreatePullRequest(ctx context.Context, pr *model.PullRequest) er
 		return err
 	}
 
-	rows, err := tx.QueryContext(ctx, `
-		SELECT id FROM users 
-		WHERE team_name = $1 AND is_active = true AND id != $2
-		ORDER BY RANDOM() 
-		LIMIT 2
-	`, teamName, pr.AuthorID)
+	count, seniorRequired, err := s.sizeRule(ctx, tx, teamName, pr.Size)
 	if err != nil {
 		return err
 	}
-	defer rows.Close()
+	dueAt, err := s.reviewDeadline(ctx, tx, teamName, time.Now())
+	if err != nil {
+		return err
+	}
+	return s.assignNewPullRequest(ctx, tx, pr, teamName, count, seniorRequired, nil, dueAt)
+}
 
+// assignNewPullRequest gives the new PR count reviewers from teamName, taking
+// them from its parent first when asked to, and fills in the PR's status, team
+// and reviewers. lineage is passed on to pickCandidates and may be nil.
+func (s *Store) assignNewPullRequest(ctx context.Context, q querier, pr *model.PullRequest, teamName string, count int, seniorRequired bool, lineage []string, dueAt *time.Time) error {
 	var reviewers []string
-	for rows.Next() {
-		var rid string
-		if err := rows.Scan(&rid); err != nil {
+	if pr.DependsOn != "" && pr.InheritReviewers {
+		inherited, hasSenior, err := s.inheritableReviewers(ctx, q, pr.DependsOn, pr.AuthorID, count)
+		if err != nil {
 			return err
 		}
-		reviewers = append(reviewers, rid)
-	}
-	rows.Close()
-
-	for _, rid := range reviewers {
-		_, err := tx.ExecContext(ctx, "INSERT INTO reviewers (pull_request_id, user_id) VALUES ($1, $2)", pr.ID, rid)
-		if err != nil {
+		if err := insertReviewersDue(ctx, q, pr.ID, inherited, dueAt); err != nil {
 			return err
 		}
+		reviewers = inherited
+		count -= len(inherited)
+		seniorRequired = seniorRequired && !hasSenior
 	}
 
+	picked, err := s.pickReviewers(ctx, q, teamName, pr.ID, pr.AuthorID, count, seniorRequired, lineage)
+	if err != nil {
+		return err
+	}
+	if err := insertReviewersDue(ctx, q, pr.ID, picked, dueAt); err != nil {
+		return err
+	}
+	reviewers = append(reviewers, picked...)
+
 	pr.Status = "OPEN"
+	pr.TargetTeam = teamName
 	pr.AssignedReviewers = reviewers
-	return tx.Commit()
+	return nil
+}
+
+// inheritableReviewers returns up to limit active reviewers of parentID other
+// than authorID and not scheduled for deactivation, seniors first, and
+// whether any of them is a senior.
+func (s *Store) inheritableReviewers(ctx context.Context, q querier, parentID, authorID string, limit int) ([]string, bool, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT u.id, u.is_senior
+		FROM reviewers r
+		JOIN users u ON r.user_id = u.id
+		WHERE r.pull_request_id = $1 AND u.is_active = true AND u.id <> $2 AND `+notLeaving+`
+		ORDER BY u.is_senior DESC, r.assigned_at
+		LIMIT $3
+	`, parentID, authorID, limit)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	hasSenior := false
+	for rows.Next() {
+		var id string
+		var isSenior bool
+		if err := rows.Scan(&id, &isSenior); err != nil {
+			return nil, false, err
+		}
+		ids = append(ids, id)
+		hasSenior = hasSenior || isSenior
+	}
+	return ids, hasSenior, rows.Err()
 }
 
 func (s *Store) MergePullRequest(ctx context.Context, prID string) (*model.PullRequest, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var status string
+	var parentStatus sql.NullString
+	err = tx.QueryRowContext(ctx, `
+		SELECT p.status, parent.status
+		FROM pull_requests p
+		LEFT JOIN pull_requests parent ON p.parent_id = parent.id
+		WHERE p.id = $1
+		FOR UPDATE OF p
+	`, prID).Scan(&status, &parentStatus)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if status == "CLOSED" {
+		return nil, ErrPRClosed
+	}
+	if parentStatus.Valid && parentStatus.String == "OPEN" {
+		return nil, ErrParentNotMerged
+	}
+	// A closed parent will never be merged, so the child is unlinked from it.
+	unlink := parentStatus.Valid && parentStatus.String == "CLOSED"
+
 	query := `
 		UPDATE pull_requests 
-		SET status = 'MERGED', merged_at = COALESCE(merged_at, CURRENT_TIMESTAMP)
+		SET status = 'MERGED', merged_at = COALESCE(merged_at, CURRENT_TIMESTAMP),
+			parent_id = CASE WHEN $2 THEN NULL ELSE parent_id END
 		WHERE id = $1
-		RETURNING id, name, author_id, status, merged_at
+		RETURNING id, name, author_id, status, COALESCE(size, ''), COALESCE(parent_id, ''),
+			COALESCE(team_name, (SELECT team_name FROM users WHERE id = author_id), ''), merged_at
 	`
 	var pr model.PullRequest
-	err := s.db.QueryRowContext(ctx, query, prID).Scan(
-		&pr.ID, &pr.Name, &pr.AuthorID, &pr.Status, &pr.MergedAt,
+	err = tx.QueryRowContext(ctx, query, prID, unlink).Scan(
+		&pr.ID, &pr.Name, &pr.AuthorID, &pr.Status, &pr.Size, &pr.DependsOn, &pr.TargetTeam, &pr.MergedAt,
 	)
-	if err == sql.ErrNoRows {
-		return nil, ErrNotFound
-	}
 	if err != nil {
 		return nil, err
 	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
 
 	reviewers, err := s.getReviewers(ctx, prID)
 	if err != nil {
@@ -106,7 +487,13 @@ func (s *Store) ReassignReviewer(ctx context.Context, prID, oldUserID string) (*
 
 	var status string
 	var authorID string
-	err = tx.QueryRowContext(ctx, "SELECT status, author_id FROM pull_requests WHERE id = $1", prID).Scan(&status, &authorID)
+	var teamName string
+	err = tx.QueryRowContext(ctx, `
+		SELECT p.status, p.author_id, COALESCE(p.team_name, a.team_name, '')
+		FROM pull_requests p
+		JOIN users a ON p.author_id = a.id
+		WHERE p.id = $1
+	`, prID).Scan(&status, &authorID, &teamName)
 	if err == sql.ErrNoRows {
 		return nil, "", ErrNotFound
 	}
@@ -117,6 +504,9 @@ func (s *Store) ReassignReviewer(ctx context.Context, prID, oldUserID string) (*
 	if status == "MERGED" {
 		return nil, "", ErrPRMerged
 	}
+	if status == "CLOSED" {
+		return nil, "", ErrPRClosed
+	}
 
 	var exists bool
 	err = tx.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM reviewers WHERE pull_request_id = $1 AND user_id = $2)", prID, oldUserID).Scan(&exists)
@@ -127,38 +517,33 @@ func (s *Store) ReassignReviewer(ctx context.Context, prID, oldUserID string) (*
 		return nil, "", ErrNotAssigned
 	}
 
-	var teamName string
-	err = tx.QueryRowContext(ctx, "SELECT team_name FROM users WHERE id = $1", oldUserID).Scan(&teamName)
-	if err == sql.ErrNoRows {
-		return nil, "", ErrNotFound
-	}
-
-	candidateQuery := `
-		SELECT u.id 
-		FROM users u
-		WHERE u.team_name = $1 
-		  AND u.is_active = true 
-		  AND u.id != $2
-		  AND u.id != $3
-		  AND u.id NOT IN (SELECT user_id FROM reviewers WHERE pull_request_id = $4)
-		ORDER BY RANDOM()
-		LIMIT 1
-	`
-	var newUserID string
-	err = tx.QueryRowContext(ctx, candidateQuery, teamName, oldUserID, authorID, prID).Scan(&newUserID)
-	if err == sql.ErrNoRows {
-		return nil, "", ErrNoCandidate
-	}
+	candidates, err := s.pickCandidates(ctx, tx, candidateQuery{
+		TeamName: teamName,
+		PrID:     prID,
+		Exclude:  []string{oldUserID, authorID},
+		Limit:    1,
+	})
 	if err != nil {
 		return nil, "", err
 	}
+	var newUserID string
+	if len(candidates) > 0 {
+		newUserID = candidates[0]
+	} else {
+		newUserID, err = s.leadFallback(ctx, tx, teamName, prID, []string{oldUserID, authorID})
+		if err != nil {
+			return nil, "", err
+		}
+		if newUserID == "" {
+			return nil, "", ErrNoCandidate
+		}
+	}
 
 	_, err = tx.ExecContext(ctx, "DELETE FROM reviewers WHERE pull_request_id = $1 AND user_id = $2", prID, oldUserID)
 	if err != nil {
 		return nil, "", err
 	}
-	_, err = tx.ExecContext(ctx, "INSERT INTO reviewers (pull_request_id, user_id) VALUES ($1, $2)", prID, newUserID)
-	if err != nil {
+	if err := s.insertReviewer(ctx, tx, prID, newUserID, teamName); err != nil {
 		return nil, "", err
 	}
 
@@ -202,10 +587,55 @@ func (s *Store) GetReviewsForUser(ctx context.Context, userID string) ([]model.P
 	return prs, nil
 }
 
+// GetPullRequest returns a PR together with the chain of PRs it depends on,
+// starting with its direct parent.
+func (s *Store) GetPullRequest(ctx context.Context, prID string) (*model.PullRequest, error) {
+	pr, err := s.getPullRequest(ctx, prID)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		WITH RECURSIVE chain AS (
+			SELECT parent.id, parent.name, parent.author_id, parent.status, parent.parent_id, 1 AS depth
+			FROM pull_requests p
+			JOIN pull_requests parent ON p.parent_id = parent.id
+			WHERE p.id = $1
+			UNION ALL
+			SELECT parent.id, parent.name, parent.author_id, parent.status, parent.parent_id, c.depth + 1
+			FROM chain c
+			JOIN pull_requests parent ON c.parent_id = parent.id
+		)
+		SELECT id, name, author_id, status FROM chain ORDER BY depth
+	`, prID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pr.DependencyChain = []model.PullRequestShort{}
+	for rows.Next() {
+		var p model.PullRequestShort
+		if err := rows.Scan(&p.ID, &p.Name, &p.AuthorID, &p.Status); err != nil {
+			return nil, err
+		}
+		pr.DependencyChain = append(pr.DependencyChain, p)
+	}
+	return pr, rows.Err()
+}
+
 func (s *Store) getPullRequest(ctx context.Context, prID string) (*model.PullRequest, error) {
 	var pr model.PullRequest
-	query := "SELECT id, name, author_id, status FROM pull_requests WHERE id = $1"
-	err := s.db.QueryRowContext(ctx, query, prID).Scan(&pr.ID, &pr.Name, &pr.AuthorID, &pr.Status)
+	query := `
+		SELECT p.id, p.name, p.author_id, p.status, COALESCE(p.size, ''), COALESCE(p.parent_id, ''), COALESCE(p.team_name, a.team_name, '')
+		FROM pull_requests p
+		JOIN users a ON p.author_id = a.id
+		WHERE p.id = $1
+	`
+	err := s.db.QueryRowContext(ctx, query, prID).Scan(&pr.ID, &pr.Name, &pr.AuthorID, &pr.Status, &pr.Size, &pr.DependsOn, &pr.TargetTeam)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,109 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// reviewAssignment is a review held by OldUser on an OPEN PR.
+type reviewAssignment struct {
+	PrID     string
+	OldUser  string
+	AuthorID string
+	TeamName string
+}
+
+// openAssignments lists the reviews the given users hold on OPEN PRs, together
+// with the PR's team that replacements should be drawn from. A non-empty
+// teamName limits the result to PRs of that team.
+func (s *Store) openAssignments(ctx context.Context, q querier, userIDs []string, teamName string) ([]reviewAssignment, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT r.pull_request_id, r.user_id, p.author_id, COALESCE(p.team_name, a.team_name, '')
+		FROM reviewers r
+		JOIN pull_requests p ON r.pull_request_id = p.id
+		JOIN users a ON p.author_id = a.id
+		WHERE r.user_id = ANY($1) AND p.status = 'OPEN'
+		  AND ($2 = '' OR COALESCE(p.team_name, a.team_name) = $2)
+		ORDER BY r.pull_request_id, r.user_id
+	`, userIDs, teamName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tasks []reviewAssignment
+	for rows.Next() {
+		var a reviewAssignment
+		if err := rows.Scan(&a.PrID, &a.OldUser, &a.AuthorID, &a.TeamName); err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, a)
+	}
+	return tasks, rows.Err()
+}
+
+// replaceReviewers swaps every task's reviewer for a random eligible member of
+// the task's team, never picking anyone in exclude. When there is no such
+// member a team lead may step in (see leadFallback); otherwise the task is
+// left as is and returned as unresolved.
+func (s *Store) replaceReviewers(ctx context.Context, tx *sql.Tx, tasks []reviewAssignment, exclude []string) ([]ReviewMove, []reviewAssignment, error) {
+	var moves []ReviewMove
+	var unresolved []reviewAssignment
+
+	stmtSwap, err := tx.PrepareContext(ctx, `
+		UPDATE reviewers SET user_id = $1, assigned_at = CURRENT_TIMESTAMP, due_at = $4, escalated_at = NULL
+		WHERE pull_request_id = $2 AND user_id = $3
+	`)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer stmtSwap.Close()
+
+	for _, task := range tasks {
+		taskExclude := append([]string{task.AuthorID}, exclude...)
+		candidates, err := s.pickCandidates(ctx, tx, candidateQuery{
+			TeamName: task.TeamName,
+			PrID:     task.PrID,
+			Exclude:  taskExclude,
+			Limit:    1,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		var newReviewerID string
+		if len(candidates) > 0 {
+			newReviewerID = candidates[0]
+		} else {
+			newReviewerID, err = s.leadFallback(ctx, tx, task.TeamName, task.PrID, taskExclude)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		if newReviewerID == "" {
+			unresolved = append(unresolved, task)
+			continue
+		}
+
+		dueAt, err := s.reviewDeadline(ctx, tx, task.TeamName, time.Now())
+		if err != nil {
+			return nil, nil, err
+		}
+		_, err = stmtSwap.ExecContext(ctx, newReviewerID, task.PrID, task.OldUser, dueAt)
+		if err != nil {
+			return nil, nil, err
+		}
+		moves = append(moves, ReviewMove{PullRequestID: task.PrID, FromUserID: task.OldUser, ToUserID: newReviewerID})
+	}
+
+	return moves, unresolved, nil
+}
+
+// reassignmentsByPR maps PR ids to the new reviewers among moves.
+func reassignmentsByPR(moves []ReviewMove) map[string][]string {
+	reassignments := make(map[string][]string)
+	for _, m := range moves {
+		reassignments[m.PullRequestID] = append(reassignments[m.PullRequestID], m.ToUserID)
+	}
+	return reassignments
+}
@@ -0,0 +1,266 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"time"
+)
+
+// ReviewMove is a review handed from one reviewer to another.
+type ReviewMove struct {
+	PullRequestID string `json:"pull_request_id"`
+	FromUserID    string `json:"from_user_id"`
+	ToUserID      string `json:"to_user_id"`
+}
+
+// teamWorkload is an in-memory view of the reviews on a team's OPEN PRs, used
+// to plan review moves between its members.
+type teamWorkload struct {
+	team       string
+	loads      map[string]int  // eligible members -> reviews held on the team's OPEN PRs
+	open       map[string]int  // eligible members -> reviews held on all OPEN PRs
+	maxOpen    int             // max_open_reviews of the team's file policy, 0 for no cap
+	leaving    map[string]bool // members with a pending scheduled deactivation
+	delegating map[string]bool // members whose new reviews go to a delegate
+	prs        map[string]*openPR
+	order      []string // PR ids, oldest first
+}
+
+type openPR struct {
+	author    string
+	reviewers map[string]bool
+}
+
+// loadTeamWorkload reads the active members of teamName, the reviews on the
+// team's OPEN PRs and what limits the members' new reviews: the team's
+// max_open_reviews cap, pending deactivations and active delegations.
+func (s *Store) loadTeamWorkload(ctx context.Context, q querier, teamName string) (*teamWorkload, error) {
+	members, err := queryIDs(ctx, q, `
+		SELECT u.id
+		FROM team_memberships m
+		JOIN users u ON m.user_id = u.id
+		WHERE m.team_name = $1 AND m.is_active = true AND u.is_active = true
+	`, teamName)
+	if err != nil {
+		return nil, err
+	}
+	leaving, err := queryIDs(ctx, q, `
+		SELECT sd.user_id
+		FROM scheduled_deactivations sd
+		JOIN team_memberships m ON m.user_id = sd.user_id
+		WHERE m.team_name = $1 AND sd.status = 'PENDING'
+	`, teamName)
+	if err != nil {
+		return nil, err
+	}
+	delegating, err := queryIDs(ctx, q, `
+		SELECT DISTINCT d.user_id
+		FROM review_delegations d
+		JOIN team_memberships m ON m.user_id = d.user_id
+		WHERE m.team_name = $1 AND `+activeDelegation+`
+	`, teamName)
+	if err != nil {
+		return nil, err
+	}
+	w := &teamWorkload{
+		team:       teamName,
+		loads:      make(map[string]int),
+		open:       make(map[string]int),
+		maxOpen:    s.assignmentPolicy(teamName).maxOpenReviews,
+		leaving:    make(map[string]bool),
+		delegating: make(map[string]bool),
+		prs:        make(map[string]*openPR),
+	}
+	for _, id := range members {
+		w.loads[id] = 0
+	}
+	for _, id := range leaving {
+		w.leaving[id] = true
+	}
+	for _, id := range delegating {
+		w.delegating[id] = true
+	}
+
+	if w.maxOpen > 0 {
+		rows, err := q.QueryContext(ctx, `
+			SELECT r.user_id, count(*)
+			FROM reviewers r
+			JOIN pull_requests p ON r.pull_request_id = p.id
+			WHERE p.status = 'OPEN' AND r.user_id = ANY($1)
+			GROUP BY r.user_id
+		`, members)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var id string
+			var n int
+			if err := rows.Scan(&id, &n); err != nil {
+				return nil, err
+			}
+			w.open[id] = n
+		}
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := q.QueryContext(ctx, `
+		SELECT p.id, p.author_id, r.user_id
+		FROM pull_requests p
+		JOIN users a ON p.author_id = a.id
+		LEFT JOIN reviewers r ON r.pull_request_id = p.id
+		WHERE p.status = 'OPEN' AND COALESCE(p.team_name, a.team_name) = $1
+		ORDER BY p.created_at, p.id
+	`, teamName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var prID, authorID string
+		var reviewer sql.NullString
+		if err := rows.Scan(&prID, &authorID, &reviewer); err != nil {
+			return nil, err
+		}
+		pr, ok := w.prs[prID]
+		if !ok {
+			pr = &openPR{author: authorID, reviewers: make(map[string]bool)}
+			w.prs[prID] = pr
+			w.order = append(w.order, prID)
+		}
+		if reviewer.Valid {
+			pr.reviewers[reviewer.String] = true
+			if _, member := w.loads[reviewer.String]; member {
+				w.loads[reviewer.String]++
+			}
+		}
+	}
+	return w, rows.Err()
+}
+
+// plan moves reviews from the most to the least loaded members, one at a
+// time, for as long as some pair differs by more than threshold and one of
+// the donor's reviews can go to the receiver. A review never goes to the PR's
+// author, to someone already reviewing the PR, to a member who is leaving or
+// delegating, or to a member at the team's max_open_reviews cap, the same
+// limits new assignments obey. If receivers is non-empty only those members
+// take reviews. threshold must be at least 1.
+func (w *teamWorkload) plan(threshold int, receivers map[string]bool) []ReviewMove {
+	moves := []ReviewMove{}
+	for {
+		move, ok := w.nextMove(threshold, receivers)
+		if !ok {
+			return moves
+		}
+		pr := w.prs[move.PullRequestID]
+		delete(pr.reviewers, move.FromUserID)
+		pr.reviewers[move.ToUserID] = true
+		w.loads[move.FromUserID]--
+		w.loads[move.ToUserID]++
+		w.open[move.FromUserID]--
+		w.open[move.ToUserID]++
+		moves = append(moves, move)
+	}
+}
+
+func (w *teamWorkload) nextMove(threshold int, receivers map[string]bool) (ReviewMove, bool) {
+	members := make([]string, 0, len(w.loads))
+	for id := range w.loads {
+		members = append(members, id)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if w.loads[members[i]] != w.loads[members[j]] {
+			return w.loads[members[i]] > w.loads[members[j]]
+		}
+		return members[i] < members[j]
+	})
+
+	for _, donor := range members {
+		for i := len(members) - 1; i >= 0; i-- {
+			receiver := members[i]
+			if w.loads[donor]-w.loads[receiver] <= threshold {
+				break
+			}
+			if w.leaving[receiver] || w.delegating[receiver] || len(receivers) > 0 && !receivers[receiver] {
+				continue
+			}
+			if w.maxOpen > 0 && w.open[receiver] >= w.maxOpen {
+				continue
+			}
+			for _, prID := range w.order {
+				pr := w.prs[prID]
+				if pr.reviewers[donor] && !pr.reviewers[receiver] && pr.author != receiver {
+					return ReviewMove{PullRequestID: prID, FromUserID: donor, ToUserID: receiver}, true
+				}
+			}
+		}
+	}
+	return ReviewMove{}, false
+}
+
+// applyMoves hands the planned reviews over, with fresh deadlines of the team.
+func (s *Store) applyMoves(ctx context.Context, q querier, teamName string, moves []ReviewMove) error {
+	for _, m := range moves {
+		dueAt, err := s.reviewDeadline(ctx, q, teamName, time.Now())
+		if err != nil {
+			return err
+		}
+		_, err = q.ExecContext(ctx, `
+			UPDATE reviewers SET user_id = $1, assigned_at = CURRENT_TIMESTAMP, due_at = $4, escalated_at = NULL
+			WHERE pull_request_id = $2 AND user_id = $3
+		`, m.ToUserID, m.PullRequestID, m.FromUserID, dueAt)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RebalanceResult lists the moves of a team rebalance and the review load of
+// every active member before and after them.
+type RebalanceResult struct {
+	TeamName    string         `json:"team_name"`
+	Threshold   int            `json:"threshold"`
+	DryRun      bool           `json:"dry_run"`
+	Moves       []ReviewMove   `json:"moves"`
+	LoadsBefore map[string]int `json:"loads_before"`
+	LoadsAfter  map[string]int `json:"loads_after"`
+}
+
+// RebalanceTeam moves reviews on the team's OPEN PRs from the most to the
+// least loaded active members until no two of them differ by more than
+// threshold. With dryRun the moves are only planned.
+func (s *Store) RebalanceTeam(ctx context.Context, teamName string, threshold int, dryRun bool) (*RebalanceResult, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockTeam(ctx, tx, teamName); err != nil {
+		return nil, err
+	}
+	w, err := s.loadTeamWorkload(ctx, tx, teamName)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RebalanceResult{TeamName: teamName, Threshold: threshold, DryRun: dryRun, LoadsBefore: make(map[string]int)}
+	for id, n := range w.loads {
+		result.LoadsBefore[id] = n
+	}
+	result.Moves = w.plan(threshold, nil)
+	result.LoadsAfter = w.loads
+
+	if dryRun {
+		return result, nil
+	}
+	if err := s.applyMoves(ctx, tx, teamName, result.Moves); err != nil {
+		return nil, err
+	}
+	return result, tx.Commit()
+}
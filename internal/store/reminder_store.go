@@ -0,0 +1,94 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"pr-reviewer/internal/model"
+)
+
+// CollectDueReminders returns one reminder per reviewer for every team whose
+// reminder interval has elapsed, and the names of those teams. A reminder
+// lists the reviewer's pending reviews on the team's OPEN PRs that were
+// assigned longer ago than the team's threshold. A team stays due until it is
+// marked with MarkReminded, so reminders that could not be delivered are
+// collected again on the next run.
+func (s *Store) CollectDueReminders(ctx context.Context, now time.Time) ([]model.ReminderEvent, []string, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT team_name, reminder_threshold_hours
+		FROM team_policies
+		WHERE reminder_interval_minutes IS NOT NULL
+		  AND (last_reminder_at IS NULL
+		       OR last_reminder_at + reminder_interval_minutes * INTERVAL '1 minute' <= $1)
+	`, now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	thresholds := make(map[string]int)
+	var teams []string
+	for rows.Next() {
+		var team string
+		var threshold int
+		if err := rows.Scan(&team, &threshold); err != nil {
+			rows.Close()
+			return nil, nil, err
+		}
+		thresholds[team] = threshold
+		teams = append(teams, team)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	var events []model.ReminderEvent
+	for _, team := range teams {
+		cutoff := now.Add(-time.Duration(thresholds[team]) * time.Hour)
+		rows, err := s.db.QueryContext(ctx, `
+			SELECT r.user_id, rev.username, p.id, p.name, p.author_id, p.status
+			FROM reviewers r
+			JOIN pull_requests p ON r.pull_request_id = p.id
+			JOIN users a ON p.author_id = a.id
+			JOIN users rev ON r.user_id = rev.id
+			WHERE COALESCE(p.team_name, a.team_name) = $1
+			  AND p.status = 'OPEN'
+			  AND COALESCE(r.assigned_at, p.created_at) < $2
+			ORDER BY r.user_id, p.created_at
+		`, team, cutoff)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for rows.Next() {
+			var reviewerID, username string
+			var pr model.PullRequestShort
+			if err := rows.Scan(&reviewerID, &username, &pr.ID, &pr.Name, &pr.AuthorID, &pr.Status); err != nil {
+				rows.Close()
+				return nil, nil, err
+			}
+			if n := len(events); n == 0 || events[n-1].TeamName != team || events[n-1].ReviewerID != reviewerID {
+				events = append(events, model.ReminderEvent{
+					TeamName:   team,
+					ReviewerID: reviewerID,
+					Username:   username,
+				})
+			}
+			last := &events[len(events)-1]
+			last.PullRequests = append(last.PullRequests, pr)
+		}
+		rows.Close()
+	}
+
+	return events, teams, nil
+}
+
+// MarkReminded records that the reminders of teams were sent at now, which
+// starts their next reminder interval.
+func (s *Store) MarkReminded(ctx context.Context, teams []string, now time.Time) error {
+	if len(teams) == 0 {
+		return nil
+	}
+	_, err := s.db.ExecContext(ctx, "UPDATE team_policies SET last_reminder_at = $1 WHERE team_name = ANY($2)", now, teams)
+	return err
+}
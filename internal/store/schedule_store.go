@@ -0,0 +1,151 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"pr-reviewer/internal/model"
+)
+
+const (
+	SchedulePending   = "PENDING"
+	ScheduleDone      = "DONE"
+	ScheduleCancelled = "CANCELLED"
+)
+
+// notLeaving is a condition on the users row u that excludes users with a
+// pending scheduled deactivation from new reviews.
+const notLeaving = `NOT EXISTS (
+	SELECT 1 FROM scheduled_deactivations sd WHERE sd.user_id = u.id AND sd.status = 'PENDING'
+)`
+
+const scheduleColumns = "id, user_id, deactivate_at, status, result, created_at, finished_at"
+
+func scanSchedule(row interface{ Scan(...any) error }) (*model.ScheduledDeactivation, error) {
+	var sd model.ScheduledDeactivation
+	var result []byte
+	err := row.Scan(&sd.ScheduleID, &sd.UserID, &sd.DeactivateAt, &sd.Status, &result, &sd.CreatedAt, &sd.FinishedAt)
+	if err != nil {
+		return nil, err
+	}
+	if result != nil {
+		sd.Result = result
+	}
+	return &sd, nil
+}
+
+// ScheduleDeactivation sets the date on which the user is deactivated. A user
+// has at most one pending date; scheduling again moves it. Deleted and
+// unknown users give ErrNotFound.
+func (s *Store) ScheduleDeactivation(ctx context.Context, userID string, at time.Time) (*model.ScheduledDeactivation, error) {
+	sd, err := scanSchedule(s.db.QueryRowContext(ctx, `
+		INSERT INTO scheduled_deactivations (user_id, deactivate_at)
+		SELECT id, $2 FROM users WHERE id = $1 AND deleted_at IS NULL
+		ON CONFLICT (user_id) WHERE status = 'PENDING' DO UPDATE SET deactivate_at = EXCLUDED.deactivate_at
+		RETURNING `+scheduleColumns, userID, at))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return sd, err
+}
+
+// ListScheduledDeactivations returns the schedules with the given status, or
+// all of them if status is empty, soonest first. A non-empty userID limits
+// the list to that user.
+func (s *Store) ListScheduledDeactivations(ctx context.Context, status, userID string) ([]model.ScheduledDeactivation, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT `+scheduleColumns+`
+		FROM scheduled_deactivations
+		WHERE ($1 = '' OR status = $1) AND ($2 = '' OR user_id = $2)
+		ORDER BY deactivate_at, user_id
+	`, status, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []model.ScheduledDeactivation{}
+	for rows.Next() {
+		sd, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *sd)
+	}
+	return list, rows.Err()
+}
+
+// GetScheduledDeactivation returns a schedule in any status.
+func (s *Store) GetScheduledDeactivation(ctx context.Context, id string) (*model.ScheduledDeactivation, error) {
+	sd, err := scanSchedule(s.db.QueryRowContext(ctx, "SELECT "+scheduleColumns+" FROM scheduled_deactivations WHERE id = $1", id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	return sd, err
+}
+
+// CancelScheduledDeactivation cancels a pending schedule. Schedules that have
+// already run or been cancelled give ErrScheduleClosed.
+func (s *Store) CancelScheduledDeactivation(ctx context.Context, id string) (*model.ScheduledDeactivation, error) {
+	sd, err := scanSchedule(s.db.QueryRowContext(ctx, `
+		UPDATE scheduled_deactivations SET status = 'CANCELLED', finished_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'PENDING'
+		RETURNING `+scheduleColumns, id))
+	if err != sql.ErrNoRows {
+		return sd, err
+	}
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM scheduled_deactivations WHERE id = $1)", id).Scan(&exists); err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+	return nil, ErrScheduleClosed
+}
+
+// RunDueDeactivations deactivates, in a single transaction, every user whose
+// pending date is at or before now, exactly like BulkDeactivateAndReassign,
+// and marks their schedules DONE with their part of the report. It returns
+// nil when nothing is due.
+func (s *Store) RunDueDeactivations(ctx context.Context, now time.Time) (*DeactivationReport, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ids, err := queryIDs(ctx, tx, `
+		SELECT user_id FROM scheduled_deactivations
+		WHERE status = 'PENDING' AND deactivate_at <= $1
+		ORDER BY deactivate_at, user_id
+		FOR UPDATE SKIP LOCKED
+	`, now)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	report, err := s.deactivateUsers(ctx, tx, ids, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range report.Users {
+		data, err := json.Marshal(u)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.ExecContext(ctx, `
+			UPDATE scheduled_deactivations SET status = 'DONE', result = $2, finished_at = CURRENT_TIMESTAMP
+			WHERE user_id = $1 AND status = 'PENDING'
+		`, u.UserID, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return report, tx.Commit()
+}
@@ -0,0 +1,149 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+)
+
+const defaultReviewerCount = 2
+
+var PRSizes = []string{"XS", "S", "M", "L", "XL"}
+
+// sizeBuckets are the upper bounds of every size but XL, by lines changed and
+// by changed files.
+var sizeBuckets = []struct {
+	Size     string
+	MaxLines int
+	MaxFiles int
+}{
+	{"XS", 10, 1},
+	{"S", 50, 3},
+	{"M", 250, 10},
+	{"L", 1000, 30},
+}
+
+// classifySize returns the size bucket of a PR. Lines changed take precedence
+// over the changed-file count; with neither the size is unknown ("").
+func classifySize(linesAdded, linesRemoved, changedFiles *int) string {
+	switch {
+	case linesAdded != nil || linesRemoved != nil:
+		lines := 0
+		if linesAdded != nil {
+			lines += *linesAdded
+		}
+		if linesRemoved != nil {
+			lines += *linesRemoved
+		}
+		for _, b := range sizeBuckets {
+			if lines <= b.MaxLines {
+				return b.Size
+			}
+		}
+		return "XL"
+	case changedFiles != nil:
+		for _, b := range sizeBuckets {
+			if *changedFiles <= b.MaxFiles {
+				return b.Size
+			}
+		}
+		return "XL"
+	default:
+		return ""
+	}
+}
+
+func validSize(size string) bool {
+	for _, s := range PRSizes {
+		if s == size {
+			return true
+		}
+	}
+	return false
+}
+
+// sizeRule returns how many reviewers a PR of the given size gets in teamName.
+func (s *Store) sizeRule(ctx context.Context, q querier, teamName, size string) (count int, seniorRequired bool, err error) {
+	if size == "" {
+		return s.assignmentPolicy(teamName).reviewerCount, false, nil
+	}
+	err = q.QueryRowContext(ctx,
+		"SELECT reviewer_count, senior_required FROM team_size_rules WHERE team_name = $1 AND size = $2",
+		teamName, size,
+	).Scan(&count, &seniorRequired)
+	if err == sql.ErrNoRows {
+		return s.assignmentPolicy(teamName).reviewerCount, false, nil
+	}
+	return count, seniorRequired, err
+}
+
+// sizeRuleKey identifies the size rule of a team for one size.
+type sizeRuleKey struct {
+	TeamName string
+	Size     string
+}
+
+// sizeRuleValue is what a size rule sets for a PR.
+type sizeRuleValue struct {
+	Count          int
+	SeniorRequired bool
+}
+
+// sizeRules returns the size rules of all teams in one query. A size without
+// a rule is missing from the map; see sizeRule for the default.
+func sizeRules(ctx context.Context, q querier, teams []string) (map[sizeRuleKey]sizeRuleValue, error) {
+	rows, err := q.QueryContext(ctx,
+		"SELECT team_name, size, reviewer_count, senior_required FROM team_size_rules WHERE team_name = ANY($1)", teams,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := make(map[sizeRuleKey]sizeRuleValue)
+	for rows.Next() {
+		var key sizeRuleKey
+		var rule sizeRuleValue
+		if err := rows.Scan(&key.TeamName, &key.Size, &rule.Count, &rule.SeniorRequired); err != nil {
+			return nil, err
+		}
+		rules[key] = rule
+	}
+	return rules, rows.Err()
+}
+
+// pickReviewers selects count reviewers for a new PR, starting with a senior
+// member when the size rule asks for one. If no senior is available the PR
+// still gets count reviewers where possible. lineage is passed on to
+// pickCandidates and may be nil.
+func (s *Store) pickReviewers(ctx context.Context, q querier, teamName, prID, authorID string, count int, seniorRequired bool, lineage []string) ([]string, error) {
+	var reviewers []string
+	if seniorRequired && count > 0 {
+		seniors, err := s.pickCandidates(ctx, q, candidateQuery{
+			TeamName:   teamName,
+			PrID:       prID,
+			Exclude:    []string{authorID},
+			SeniorOnly: true,
+			Limit:      1,
+			Lineage:    lineage,
+		})
+		if err != nil {
+			return nil, err
+		}
+		reviewers = seniors
+	}
+
+	if rest := count - len(reviewers); rest > 0 {
+		others, err := s.pickCandidates(ctx, q, candidateQuery{
+			TeamName: teamName,
+			PrID:     prID,
+			Exclude:  append([]string{authorID}, reviewers...),
+			Limit:    rest,
+			Lineage:  lineage,
+		})
+		if err != nil {
+			return nil, err
+		}
+		reviewers = append(reviewers, others...)
+	}
+	return reviewers, nil
+}
@@ -0,0 +1,213 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+const (
+	SLAActionReassign  = "REASSIGN"
+	SLAActionAddBackup = "ADD_BACKUP"
+)
+
+const (
+	defaultTimezone     = "UTC"
+	defaultWorkDayStart = 9
+	defaultWorkDayEnd   = 17
+)
+
+type Escalation struct {
+	PullRequestID string `json:"pull_request_id"`
+	ReviewerID    string `json:"reviewer_id"`
+	Action        string `json:"action"`
+	NewReviewerID string `json:"new_reviewer_id,omitempty"`
+}
+
+// reviewDeadline returns when a review assigned at from is due according to
+// the SLA of teamName, or nil if the team has no SLA.
+func (s *Store) reviewDeadline(ctx context.Context, q querier, teamName string, from time.Time) (*time.Time, error) {
+	var slaHours sql.NullInt64
+	var timezone string
+	var dayStart, dayEnd int
+	err := q.QueryRowContext(ctx,
+		"SELECT review_sla_hours, timezone, work_day_start, work_day_end FROM team_policies WHERE team_name = $1", teamName,
+	).Scan(&slaHours, &timezone, &dayStart, &dayEnd)
+	if err == sql.ErrNoRows || (err == nil && !slaHours.Valid) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return deadline(from, int(slaHours.Int64), timezone, dayStart, dayEnd), nil
+}
+
+// reviewDeadlines returns reviewDeadline for each of teams in one query. Teams
+// without an SLA map to nil.
+func (s *Store) reviewDeadlines(ctx context.Context, q querier, teams []string, from time.Time) (map[string]*time.Time, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT team_name, review_sla_hours, timezone, work_day_start, work_day_end FROM team_policies
+		WHERE team_name = ANY($1) AND review_sla_hours IS NOT NULL
+	`, teams)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deadlines := make(map[string]*time.Time)
+	for rows.Next() {
+		var teamName, timezone string
+		var slaHours, dayStart, dayEnd int
+		if err := rows.Scan(&teamName, &slaHours, &timezone, &dayStart, &dayEnd); err != nil {
+			return nil, err
+		}
+		deadlines[teamName] = deadline(from, slaHours, timezone, dayStart, dayEnd)
+	}
+	return deadlines, rows.Err()
+}
+
+// deadline returns the time slaHours working hours after from in timezone,
+// falling back to UTC for an unknown timezone.
+func deadline(from time.Time, slaHours int, timezone string, dayStart, dayEnd int) *time.Time {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	due := addWorkingHours(from, slaHours, loc, dayStart, dayEnd)
+	return &due
+}
+
+// addWorkingHours moves t forward by the given number of hours, counting only
+// time between dayStart and dayEnd o'clock of a Monday to Friday in loc.
+func addWorkingHours(t time.Time, hours int, loc *time.Location, dayStart, dayEnd int) time.Time {
+	t = t.In(loc)
+	left := time.Duration(hours) * time.Hour
+	for left > 0 {
+		y, m, d := t.Date()
+		opens := time.Date(y, m, d, dayStart, 0, 0, 0, loc)
+		closes := time.Date(y, m, d, dayEnd, 0, 0, 0, loc)
+		if wd := t.Weekday(); wd == time.Saturday || wd == time.Sunday || !t.Before(closes) {
+			t = time.Date(y, m, d+1, dayStart, 0, 0, 0, loc)
+			continue
+		}
+		if t.Before(opens) {
+			t = opens
+		}
+		if closes.Sub(t) >= left {
+			return t.Add(left)
+		}
+		left -= closes.Sub(t)
+		t = closes
+	}
+	return t
+}
+
+// EscalateOverdueReviews handles every review on an OPEN PR whose deadline is
+// before now. Depending on the policy of the PR's team the late reviewer is
+// either replaced or joined by a backup reviewer. Each review is escalated once
+// and every original reviewer gets at most one backup: a late backup is
+// replaced instead of getting a backup of its own.
+func (s *Store) EscalateOverdueReviews(ctx context.Context, now time.Time) ([]Escalation, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, `
+		SELECT r.pull_request_id, r.user_id, COALESCE(r.backup_for, ''), p.author_id, COALESCE(p.team_name, u.team_name, ''),
+		       COALESCE(tp.sla_action, 'REASSIGN')
+		FROM reviewers r
+		JOIN pull_requests p ON r.pull_request_id = p.id
+		JOIN users u ON p.author_id = u.id
+		LEFT JOIN team_policies tp ON tp.team_name = COALESCE(p.team_name, u.team_name)
+		WHERE p.status = 'OPEN' AND r.due_at < $1 AND r.escalated_at IS NULL
+		ORDER BY r.due_at
+		FOR UPDATE OF r SKIP LOCKED
+	`, now)
+	if err != nil {
+		return nil, err
+	}
+
+	type overdue struct {
+		PrID      string
+		Reviewer  string
+		BackupFor string
+		AuthorID  string
+		TeamName  string
+		Action    string
+	}
+	var tasks []overdue
+	for rows.Next() {
+		var o overdue
+		if err := rows.Scan(&o.PrID, &o.Reviewer, &o.BackupFor, &o.AuthorID, &o.TeamName, &o.Action); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		tasks = append(tasks, o)
+	}
+	rows.Close()
+
+	var escalations []Escalation
+	for _, task := range tasks {
+		e := Escalation{PullRequestID: task.PrID, ReviewerID: task.Reviewer}
+
+		candidates, err := s.pickCandidates(ctx, tx, candidateQuery{
+			TeamName: task.TeamName,
+			PrID:     task.PrID,
+			Exclude:  []string{task.AuthorID},
+			Limit:    1,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(candidates) == 0 {
+			e.Action = "NO_CANDIDATE"
+			_, err = tx.ExecContext(ctx,
+				"UPDATE reviewers SET escalated_at = $1 WHERE pull_request_id = $2 AND user_id = $3",
+				now, task.PrID, task.Reviewer,
+			)
+			if err != nil {
+				return nil, err
+			}
+			escalations = append(escalations, e)
+			continue
+		}
+		e.NewReviewerID = candidates[0]
+
+		backupFor := task.BackupFor
+		if task.Action == SLAActionAddBackup && task.BackupFor == "" {
+			e.Action = "BACKUP_ADDED"
+			backupFor = task.Reviewer
+			_, err = tx.ExecContext(ctx,
+				"UPDATE reviewers SET escalated_at = $1 WHERE pull_request_id = $2 AND user_id = $3",
+				now, task.PrID, task.Reviewer,
+			)
+		} else {
+			e.Action = "REASSIGNED"
+			_, err = tx.ExecContext(ctx,
+				"DELETE FROM reviewers WHERE pull_request_id = $1 AND user_id = $2",
+				task.PrID, task.Reviewer,
+			)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if err := s.insertReviewer(ctx, tx, task.PrID, e.NewReviewerID, task.TeamName); err != nil {
+			return nil, err
+		}
+		if backupFor != "" {
+			_, err = tx.ExecContext(ctx,
+				"UPDATE reviewers SET backup_for = $1 WHERE pull_request_id = $2 AND user_id = $3",
+				backupFor, task.PrID, e.NewReviewerID,
+			)
+			if err != nil {
+				return nil, err
+			}
+		}
+		escalations = append(escalations, e)
+	}
+
+	return escalations, tx.Commit()
+}
@@ -0,0 +1,946 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"pr-reviewer/internal/model"
+)
+
+// SnapshotVersion is the format version written by ExportSnapshot. Import
+// accepts versions 1 through SnapshotVersion. Version 2 added scheduled
+// deactivations and review delegations; version 1 snapshots simply have none.
+const SnapshotVersion = 2
+
+// Snapshot is a schema-independent copy of all data: teams with their leads
+// and policies, users with their memberships, live and archived PRs with
+// their reviewers, scheduled deactivations and review delegations. Jobs are
+// not included.
+type Snapshot struct {
+	Version                int                   `json:"version"`
+	ExportedAt             time.Time             `json:"exported_at"`
+	Teams                  []SnapshotTeam        `json:"teams"`
+	Users                  []SnapshotUser        `json:"users"`
+	PullRequests           []SnapshotPullRequest `json:"pull_requests"`
+	ArchivedPullRequests   []SnapshotPullRequest `json:"archived_pull_requests"`
+	ScheduledDeactivations []SnapshotSchedule    `json:"scheduled_deactivations"`
+	Delegations            []SnapshotDelegation  `json:"delegations"`
+}
+
+type SnapshotTeam struct {
+	Name       string            `json:"team_name"`
+	ParentTeam string            `json:"parent_team,omitempty"`
+	ArchivedAt *time.Time        `json:"archived_at,omitempty"`
+	Leads      []string          `json:"leads"`
+	Policy     *model.TeamPolicy `json:"policy,omitempty"`
+}
+
+type SnapshotUser struct {
+	ID          string               `json:"user_id"`
+	Username    string               `json:"username"`
+	Email       string               `json:"email,omitempty"`
+	Handle      string               `json:"handle,omitempty"`
+	IsActive    bool                 `json:"is_active"`
+	IsSenior    bool                 `json:"is_senior"`
+	PrimaryTeam string               `json:"primary_team,omitempty"`
+	DeletedAt   *time.Time           `json:"deleted_at,omitempty"`
+	Memberships []SnapshotMembership `json:"memberships"`
+}
+
+type SnapshotMembership struct {
+	TeamName string     `json:"team_name"`
+	IsActive bool       `json:"is_active"`
+	JoinedAt *time.Time `json:"joined_at,omitempty"`
+}
+
+type SnapshotPullRequest struct {
+	ID           string             `json:"pull_request_id"`
+	Name         string             `json:"pull_request_name"`
+	AuthorID     string             `json:"author_id"`
+	Status       string             `json:"status"`
+	TeamName     string             `json:"team_name,omitempty"`
+	DependsOn    string             `json:"depends_on,omitempty"`
+	LinesAdded   *int               `json:"lines_added,omitempty"`
+	LinesRemoved *int               `json:"lines_removed,omitempty"`
+	ChangedFiles *int               `json:"changed_files,omitempty"`
+	Size         string             `json:"size,omitempty"`
+	CreatedAt    *time.Time         `json:"created_at,omitempty"`
+	MergedAt     *time.Time         `json:"merged_at,omitempty"`
+	ClosedAt     *time.Time         `json:"closed_at,omitempty"`
+	ArchivedAt   *time.Time         `json:"archived_at,omitempty"`
+	Reviewers    []SnapshotReviewer `json:"reviewers"`
+}
+
+type SnapshotReviewer struct {
+	UserID      string     `json:"user_id"`
+	AssignedAt  *time.Time `json:"assigned_at,omitempty"`
+	DueAt       *time.Time `json:"due_at,omitempty"`
+	EscalatedAt *time.Time `json:"escalated_at,omitempty"`
+	BackupFor   string     `json:"backup_for,omitempty"`
+}
+
+// SnapshotSchedule is a scheduled deactivation with its history; Result is
+// the user's deactivation report once it has run.
+type SnapshotSchedule struct {
+	ID           string          `json:"schedule_id,omitempty"`
+	UserID       string          `json:"user_id"`
+	DeactivateAt time.Time       `json:"deactivate_at"`
+	Status       string          `json:"status"`
+	Result       json.RawMessage `json:"result,omitempty"`
+	CreatedAt    *time.Time      `json:"created_at,omitempty"`
+	FinishedAt   *time.Time      `json:"finished_at,omitempty"`
+}
+
+// SnapshotDelegation is a review delegation, including expired and cancelled
+// ones. Its status is derived from the times and not stored.
+type SnapshotDelegation struct {
+	ID              string     `json:"delegation_id,omitempty"`
+	UserID          string     `json:"user_id"`
+	DelegateID      string     `json:"delegate_id"`
+	StartsAt        time.Time  `json:"starts_at"`
+	EndsAt          time.Time  `json:"ends_at"`
+	TransferReviews bool       `json:"transfer_reviews"`
+	TransferredAt   *time.Time `json:"transferred_at,omitempty"`
+	CreatedAt       *time.Time `json:"created_at,omitempty"`
+	CancelledAt     *time.Time `json:"cancelled_at,omitempty"`
+}
+
+type ImportResult struct {
+	Teams                int `json:"teams"`
+	Users                int `json:"users"`
+	Memberships          int `json:"memberships"`
+	PullRequests         int `json:"pull_requests"`
+	Reviews              int `json:"reviews"`
+	ArchivedPullRequests int `json:"archived_pull_requests"`
+	ArchivedReviews      int `json:"archived_reviews"`
+	Schedules            int `json:"scheduled_deactivations"`
+	Delegations          int `json:"delegations"`
+}
+
+// ExportSnapshot reads all data in one consistent, read-only transaction.
+func (s *Store) ExportSnapshot(ctx context.Context) (*Snapshot, error) {
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	snap := &Snapshot{Version: SnapshotVersion, ExportedAt: time.Now().UTC()}
+	if snap.Teams, err = exportTeams(ctx, tx); err != nil {
+		return nil, err
+	}
+	if snap.Users, err = exportUsers(ctx, tx); err != nil {
+		return nil, err
+	}
+	if snap.PullRequests, err = exportPullRequests(ctx, tx); err != nil {
+		return nil, err
+	}
+	if snap.ArchivedPullRequests, err = exportArchivedPullRequests(ctx, tx); err != nil {
+		return nil, err
+	}
+	if snap.ScheduledDeactivations, err = exportSchedules(ctx, tx); err != nil {
+		return nil, err
+	}
+	if snap.Delegations, err = exportDelegations(ctx, tx); err != nil {
+		return nil, err
+	}
+	return snap, tx.Commit()
+}
+
+func exportTeams(ctx context.Context, q querier) ([]SnapshotTeam, error) {
+	rows, err := q.QueryContext(ctx, "SELECT name, COALESCE(parent_team, ''), archived_at FROM teams ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	teams := []SnapshotTeam{}
+	index := make(map[string]int)
+	for rows.Next() {
+		team := SnapshotTeam{Leads: []string{}}
+		if err := rows.Scan(&team.Name, &team.ParentTeam, &team.ArchivedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		index[team.Name] = len(teams)
+		teams = append(teams, team)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = q.QueryContext(ctx, "SELECT team_name, user_id FROM team_leads ORDER BY team_name, user_id")
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var team, user string
+		if err := rows.Scan(&team, &user); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		teams[index[team]].Leads = append(teams[index[team]].Leads, user)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = q.QueryContext(ctx, `
+		SELECT team_name, review_sla_hours, sla_action, reminder_interval_minutes, reminder_threshold_hours,
+		       no_candidate_action, leads_only_management, timezone, work_day_start, work_day_end
+		FROM team_policies
+	`)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		policy := &model.TeamPolicy{SizeRules: []model.SizeRule{}}
+		var threshold, dayStart, dayEnd int
+		if err := rows.Scan(&policy.TeamName, &policy.ReviewSLAHours, &policy.SLAAction,
+			&policy.ReminderIntervalMinutes, &threshold, &policy.NoCandidateAction, &policy.LeadsOnlyManagement,
+			&policy.Timezone, &dayStart, &dayEnd); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		policy.ReminderThresholdHours = &threshold
+		policy.WorkDayStart, policy.WorkDayEnd = &dayStart, &dayEnd
+		teams[index[policy.TeamName]].Policy = policy
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = q.QueryContext(ctx, `
+		SELECT team_name, size, reviewer_count, senior_required FROM team_size_rules
+		ORDER BY team_name, array_position(ARRAY['XS', 'S', 'M', 'L', 'XL']::varchar[], size)
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var team string
+		var rule model.SizeRule
+		if err := rows.Scan(&team, &rule.Size, &rule.ReviewerCount, &rule.SeniorRequired); err != nil {
+			return nil, err
+		}
+		t := &teams[index[team]]
+		if t.Policy == nil {
+			t.Policy = &model.TeamPolicy{TeamName: team, SizeRules: []model.SizeRule{}}
+			applyPolicyDefaults(t.Policy)
+		}
+		t.Policy.SizeRules = append(t.Policy.SizeRules, rule)
+	}
+	return teams, rows.Err()
+}
+
+func exportUsers(ctx context.Context, q querier) ([]SnapshotUser, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT id, username, COALESCE(email, ''), COALESCE(handle, ''), is_active, is_senior,
+		       COALESCE(team_name, ''), deleted_at
+		FROM users ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	users := []SnapshotUser{}
+	index := make(map[string]int)
+	for rows.Next() {
+		u := SnapshotUser{Memberships: []SnapshotMembership{}}
+		if err := rows.Scan(&u.ID, &u.Username, &u.Email, &u.Handle, &u.IsActive, &u.IsSenior,
+			&u.PrimaryTeam, &u.DeletedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		index[u.ID] = len(users)
+		users = append(users, u)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = q.QueryContext(ctx, `
+		SELECT user_id, team_name, is_active, created_at FROM team_memberships
+		ORDER BY user_id, created_at, team_name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var user string
+		var m SnapshotMembership
+		if err := rows.Scan(&user, &m.TeamName, &m.IsActive, &m.JoinedAt); err != nil {
+			return nil, err
+		}
+		users[index[user]].Memberships = append(users[index[user]].Memberships, m)
+	}
+	return users, rows.Err()
+}
+
+func exportPullRequests(ctx context.Context, q querier) ([]SnapshotPullRequest, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT id, name, author_id, status, COALESCE(team_name, ''), COALESCE(parent_id, ''),
+		       lines_added, lines_removed, changed_files, COALESCE(size, ''), created_at, merged_at, closed_at
+		FROM pull_requests ORDER BY created_at, id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	prs := []SnapshotPullRequest{}
+	index := make(map[string]int)
+	for rows.Next() {
+		pr := SnapshotPullRequest{Reviewers: []SnapshotReviewer{}}
+		if err := rows.Scan(&pr.ID, &pr.Name, &pr.AuthorID, &pr.Status, &pr.TeamName, &pr.DependsOn,
+			&pr.LinesAdded, &pr.LinesRemoved, &pr.ChangedFiles, &pr.Size, &pr.CreatedAt, &pr.MergedAt, &pr.ClosedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		index[pr.ID] = len(prs)
+		prs = append(prs, pr)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = q.QueryContext(ctx, `
+		SELECT pull_request_id, user_id, assigned_at, due_at, escalated_at, COALESCE(backup_for, '') FROM reviewers
+		ORDER BY pull_request_id, assigned_at, user_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var prID string
+		var r SnapshotReviewer
+		if err := rows.Scan(&prID, &r.UserID, &r.AssignedAt, &r.DueAt, &r.EscalatedAt, &r.BackupFor); err != nil {
+			return nil, err
+		}
+		prs[index[prID]].Reviewers = append(prs[index[prID]].Reviewers, r)
+	}
+	return prs, rows.Err()
+}
+
+// exportArchivedPullRequests reads the archive. Archived reviews keep no
+// backup_for.
+func exportArchivedPullRequests(ctx context.Context, q querier) ([]SnapshotPullRequest, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT id, name, author_id, status, COALESCE(team_name, ''), COALESCE(parent_id, ''),
+		       lines_added, lines_removed, changed_files, COALESCE(size, ''), created_at, merged_at, closed_at, archived_at
+		FROM pull_requests_archive ORDER BY archived_at, id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	prs := []SnapshotPullRequest{}
+	index := make(map[string]int)
+	for rows.Next() {
+		pr := SnapshotPullRequest{Reviewers: []SnapshotReviewer{}}
+		if err := rows.Scan(&pr.ID, &pr.Name, &pr.AuthorID, &pr.Status, &pr.TeamName, &pr.DependsOn,
+			&pr.LinesAdded, &pr.LinesRemoved, &pr.ChangedFiles, &pr.Size, &pr.CreatedAt, &pr.MergedAt, &pr.ClosedAt, &pr.ArchivedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		index[pr.ID] = len(prs)
+		prs = append(prs, pr)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = q.QueryContext(ctx, `
+		SELECT pull_request_id, user_id, assigned_at, due_at, escalated_at FROM reviewers_archive
+		ORDER BY pull_request_id, assigned_at, user_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var prID string
+		var r SnapshotReviewer
+		if err := rows.Scan(&prID, &r.UserID, &r.AssignedAt, &r.DueAt, &r.EscalatedAt); err != nil {
+			return nil, err
+		}
+		// The archive has no foreign keys; skip reviews of PRs it lacks.
+		if i, ok := index[prID]; ok {
+			prs[i].Reviewers = append(prs[i].Reviewers, r)
+		}
+	}
+	return prs, rows.Err()
+}
+
+func exportSchedules(ctx context.Context, q querier) ([]SnapshotSchedule, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT id, user_id, deactivate_at, status, result, created_at, finished_at
+		FROM scheduled_deactivations ORDER BY created_at, id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	schedules := []SnapshotSchedule{}
+	for rows.Next() {
+		var sd SnapshotSchedule
+		var result []byte
+		if err := rows.Scan(&sd.ID, &sd.UserID, &sd.DeactivateAt, &sd.Status, &result, &sd.CreatedAt, &sd.FinishedAt); err != nil {
+			return nil, err
+		}
+		if result != nil {
+			sd.Result = result
+		}
+		schedules = append(schedules, sd)
+	}
+	return schedules, rows.Err()
+}
+
+func exportDelegations(ctx context.Context, q querier) ([]SnapshotDelegation, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT id, user_id, delegate_id, starts_at, ends_at, transfer_reviews, transferred_at, created_at, cancelled_at
+		FROM review_delegations ORDER BY starts_at, id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	delegations := []SnapshotDelegation{}
+	for rows.Next() {
+		var d SnapshotDelegation
+		if err := rows.Scan(&d.ID, &d.UserID, &d.DelegateID, &d.StartsAt, &d.EndsAt, &d.TransferReviews,
+			&d.TransferredAt, &d.CreatedAt, &d.CancelledAt); err != nil {
+			return nil, err
+		}
+		delegations = append(delegations, d)
+	}
+	return delegations, rows.Err()
+}
+
+// ImportSnapshot validates snap and loads it into an empty database in a
+// single transaction. It fails with ErrNotEmpty if any team, user, PR or
+// archived PR exists, and with ErrInvalidSnapshot listing every problem found.
+func (s *Store) ImportSnapshot(ctx context.Context, snap *Snapshot) (*ImportResult, error) {
+	if err := snap.validate(); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Keep concurrent writers out while checking that the database is empty.
+	_, err = tx.ExecContext(ctx, "LOCK TABLE teams, users, pull_requests, pull_requests_archive IN SHARE ROW EXCLUSIVE MODE")
+	if err != nil {
+		return nil, err
+	}
+	var hasData bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM teams) OR EXISTS(SELECT 1 FROM users) OR EXISTS(SELECT 1 FROM pull_requests)
+		    OR EXISTS(SELECT 1 FROM pull_requests_archive)
+	`).Scan(&hasData)
+	if err != nil {
+		return nil, err
+	}
+	if hasData {
+		return nil, ErrNotEmpty
+	}
+
+	result := &ImportResult{}
+	for _, team := range snap.Teams {
+		_, err := tx.ExecContext(ctx, "INSERT INTO teams (name, archived_at) VALUES ($1, $2)", team.Name, team.ArchivedAt)
+		if err != nil {
+			return nil, err
+		}
+		result.Teams++
+	}
+	for _, team := range snap.Teams {
+		if team.ParentTeam == "" {
+			continue
+		}
+		_, err := tx.ExecContext(ctx, "UPDATE teams SET parent_team = $2 WHERE name = $1", team.Name, team.ParentTeam)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, u := range snap.Users {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO users (id, username, team_name, is_active, is_senior, email, handle, deleted_at)
+			VALUES ($1, $2, NULLIF($3, ''), $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8)
+		`, u.ID, u.Username, u.PrimaryTeam, u.IsActive, u.IsSenior, u.Email, u.Handle, u.DeletedAt)
+		if err != nil {
+			return nil, err
+		}
+		result.Users++
+		for _, m := range u.Memberships {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO team_memberships (team_name, user_id, is_active, created_at)
+				VALUES ($1, $2, $3, COALESCE($4, CURRENT_TIMESTAMP))
+			`, m.TeamName, u.ID, m.IsActive, m.JoinedAt)
+			if err != nil {
+				return nil, err
+			}
+			result.Memberships++
+		}
+	}
+
+	for _, team := range snap.Teams {
+		for _, lead := range team.Leads {
+			_, err := tx.ExecContext(ctx, "INSERT INTO team_leads (team_name, user_id) VALUES ($1, $2)", team.Name, lead)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if team.Policy != nil {
+			if err := writeTeamPolicy(ctx, tx, team.Policy); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, pr := range snap.PullRequests {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO pull_requests (id, name, author_id, status, team_name, lines_added, lines_removed, changed_files,
+			                           size, created_at, merged_at, closed_at)
+			VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, NULLIF($9, ''), COALESCE($10, CURRENT_TIMESTAMP), $11,
+			        CASE WHEN $4 = 'CLOSED' THEN COALESCE($12, CURRENT_TIMESTAMP) END)
+		`, pr.ID, pr.Name, pr.AuthorID, pr.Status, pr.TeamName, pr.LinesAdded, pr.LinesRemoved, pr.ChangedFiles,
+			pr.Size, pr.CreatedAt, pr.MergedAt, pr.ClosedAt)
+		if err != nil {
+			return nil, err
+		}
+		result.PullRequests++
+		for _, r := range pr.Reviewers {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO reviewers (pull_request_id, user_id, assigned_at, due_at, escalated_at, backup_for)
+				VALUES ($1, $2, COALESCE($3, CURRENT_TIMESTAMP), $4, $5, NULLIF($6, ''))
+			`, pr.ID, r.UserID, r.AssignedAt, r.DueAt, r.EscalatedAt, r.BackupFor)
+			if err != nil {
+				return nil, err
+			}
+			result.Reviews++
+		}
+	}
+	for _, pr := range snap.PullRequests {
+		if pr.DependsOn == "" {
+			continue
+		}
+		_, err := tx.ExecContext(ctx, "UPDATE pull_requests SET parent_id = $2 WHERE id = $1", pr.ID, pr.DependsOn)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, pr := range snap.ArchivedPullRequests {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO pull_requests_archive (id, name, author_id, status, team_name, parent_id, lines_added, lines_removed,
+			                                   changed_files, size, created_at, merged_at, closed_at, archived_at)
+			VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7, $8, $9, NULLIF($10, ''), $11, $12, $13,
+			        COALESCE($14, CURRENT_TIMESTAMP))
+		`, pr.ID, pr.Name, pr.AuthorID, pr.Status, pr.TeamName, pr.DependsOn, pr.LinesAdded, pr.LinesRemoved,
+			pr.ChangedFiles, pr.Size, pr.CreatedAt, pr.MergedAt, pr.ClosedAt, pr.ArchivedAt)
+		if err != nil {
+			return nil, err
+		}
+		result.ArchivedPullRequests++
+		for _, r := range pr.Reviewers {
+			_, err := tx.ExecContext(ctx, `
+				INSERT INTO reviewers_archive (pull_request_id, user_id, assigned_at, due_at, escalated_at)
+				VALUES ($1, $2, $3, $4, $5)
+			`, pr.ID, r.UserID, r.AssignedAt, r.DueAt, r.EscalatedAt)
+			if err != nil {
+				return nil, err
+			}
+			result.ArchivedReviews++
+		}
+	}
+
+	for _, sd := range snap.ScheduledDeactivations {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO scheduled_deactivations (id, user_id, deactivate_at, status, result, created_at, finished_at)
+			VALUES (COALESCE(NULLIF($1, ''), gen_random_uuid()::text), $2, $3, $4, $5, COALESCE($6, CURRENT_TIMESTAMP), $7)
+		`, sd.ID, sd.UserID, sd.DeactivateAt, sd.Status, []byte(sd.Result), sd.CreatedAt, sd.FinishedAt)
+		if err != nil {
+			return nil, err
+		}
+		result.Schedules++
+	}
+
+	for _, d := range snap.Delegations {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO review_delegations
+				(id, user_id, delegate_id, starts_at, ends_at, transfer_reviews, transferred_at, created_at, cancelled_at)
+			VALUES (COALESCE(NULLIF($1, ''), gen_random_uuid()::text), $2, $3, $4, $5, $6, $7, COALESCE($8, CURRENT_TIMESTAMP), $9)
+		`, d.ID, d.UserID, d.DelegateID, d.StartsAt, d.EndsAt, d.TransferReviews, d.TransferredAt, d.CreatedAt, d.CancelledAt)
+		if err != nil {
+			return nil, err
+		}
+		result.Delegations++
+	}
+
+	return result, tx.Commit()
+}
+
+// validate checks that the snapshot is self-contained and consistent, and
+// fills in policy defaults.
+func (snap *Snapshot) validate() error {
+	var problems []string
+	addf := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if snap.Version < 1 || snap.Version > SnapshotVersion {
+		addf("unsupported version %d, expected 1 to %d", snap.Version, SnapshotVersion)
+		return fmt.Errorf("%w: %s", ErrInvalidSnapshot, strings.Join(problems, "; "))
+	}
+
+	teams := make(map[string]*SnapshotTeam)
+	for i := range snap.Teams {
+		team := &snap.Teams[i]
+		if team.Name == "" {
+			addf("team %d has no team_name", i)
+			continue
+		}
+		if teams[team.Name] != nil {
+			addf("duplicate team %q", team.Name)
+		}
+		teams[team.Name] = team
+	}
+
+	users := make(map[string]*SnapshotUser)
+	emails := make(map[string]bool)
+	handles := make(map[string]bool)
+	for i := range snap.Users {
+		u := &snap.Users[i]
+		if u.ID == "" || u.Username == "" {
+			addf("user %d needs user_id and username", i)
+			continue
+		}
+		if users[u.ID] != nil {
+			addf("duplicate user %q", u.ID)
+		}
+		users[u.ID] = u
+
+		if u.DeletedAt == nil && u.Email != "" {
+			if emails[u.Email] {
+				addf("email %q is used by several users", u.Email)
+			}
+			emails[u.Email] = true
+		}
+		if u.DeletedAt == nil && u.Handle != "" {
+			if handles[u.Handle] {
+				addf("handle %q is used by several users", u.Handle)
+			}
+			handles[u.Handle] = true
+		}
+
+		joined := make(map[string]bool)
+		for _, m := range u.Memberships {
+			if teams[m.TeamName] == nil {
+				addf("user %q is a member of unknown team %q", u.ID, m.TeamName)
+			}
+			if joined[m.TeamName] {
+				addf("user %q is a member of %q twice", u.ID, m.TeamName)
+			}
+			joined[m.TeamName] = true
+		}
+		if u.PrimaryTeam != "" && !joined[u.PrimaryTeam] {
+			addf("user %q has primary team %q but is not a member of it", u.ID, u.PrimaryTeam)
+		}
+	}
+
+	for i := range snap.Teams {
+		team := &snap.Teams[i]
+		name := team.Name
+		if name == "" {
+			continue
+		}
+		if team.ParentTeam != "" {
+			if teams[team.ParentTeam] == nil {
+				addf("team %q has unknown parent team %q", name, team.ParentTeam)
+			} else {
+				// A walk longer than the number of teams means a cycle.
+				parent := team.ParentTeam
+				for steps := 0; parent != "" && teams[parent] != nil; steps++ {
+					if parent == name || steps > len(teams) {
+						addf("the ancestors of team %q form a cycle", name)
+						break
+					}
+					parent = teams[parent].ParentTeam
+				}
+			}
+		}
+		for _, lead := range team.Leads {
+			if users[lead] == nil {
+				addf("team %q has unknown lead %q", name, lead)
+			}
+		}
+		if team.Policy != nil {
+			team.Policy.TeamName = name
+			applyPolicyDefaults(team.Policy)
+			if err := validatePolicy(team.Policy); err != nil {
+				addf("team %q: %v", name, err)
+			}
+		}
+	}
+
+	prs := make(map[string]bool)
+	parents := make(map[string]string)
+	for _, pr := range snap.PullRequests {
+		if pr.ID == "" || pr.Name == "" || pr.AuthorID == "" {
+			addf("pull request %q needs pull_request_id, pull_request_name and author_id", pr.ID)
+			continue
+		}
+		if prs[pr.ID] {
+			addf("duplicate pull request %q", pr.ID)
+		}
+		prs[pr.ID] = true
+		parents[pr.ID] = pr.DependsOn
+	}
+	for _, pr := range snap.PullRequests {
+		if pr.ID == "" {
+			continue
+		}
+		if pr.AuthorID != "" && users[pr.AuthorID] == nil {
+			addf("pull request %q has unknown author %q", pr.ID, pr.AuthorID)
+		}
+		if pr.Status != "OPEN" && pr.Status != "MERGED" && pr.Status != "CLOSED" {
+			addf("pull request %q has unknown status %q", pr.ID, pr.Status)
+		}
+		if pr.Size != "" && !validSize(pr.Size) {
+			addf("pull request %q has unknown size %q", pr.ID, pr.Size)
+		}
+		if pr.TeamName != "" && teams[pr.TeamName] == nil {
+			addf("pull request %q has unknown team %q", pr.ID, pr.TeamName)
+		}
+		if pr.DependsOn != "" {
+			if pr.DependsOn == pr.ID || !prs[pr.DependsOn] {
+				addf("pull request %q depends on unknown pull request %q", pr.ID, pr.DependsOn)
+			} else {
+				// A walk longer than the number of PRs means a cycle, and no
+				// PR in it could ever be merged.
+				parent := pr.DependsOn
+				for steps := 0; parent != ""; steps++ {
+					if parent == pr.ID || steps > len(parents) {
+						addf("the dependencies of pull request %q form a cycle", pr.ID)
+						break
+					}
+					parent = parents[parent]
+				}
+			}
+		}
+		seen := make(map[string]bool)
+		for _, r := range pr.Reviewers {
+			if users[r.UserID] == nil {
+				addf("pull request %q has unknown reviewer %q", pr.ID, r.UserID)
+			}
+			if seen[r.UserID] {
+				addf("pull request %q lists reviewer %q twice", pr.ID, r.UserID)
+			}
+			seen[r.UserID] = true
+		}
+	}
+
+	// The archive keeps PRs of users and teams that may since have been
+	// removed, so only its own consistency is checked. Archived ids stay
+	// reserved and must not clash with live PRs.
+	archived := make(map[string]bool)
+	for _, pr := range snap.ArchivedPullRequests {
+		if pr.ID == "" || pr.Name == "" || pr.AuthorID == "" {
+			addf("archived pull request %q needs pull_request_id, pull_request_name and author_id", pr.ID)
+			continue
+		}
+		if archived[pr.ID] {
+			addf("duplicate archived pull request %q", pr.ID)
+		}
+		if prs[pr.ID] {
+			addf("pull request %q is both live and archived", pr.ID)
+		}
+		archived[pr.ID] = true
+		if pr.Status != "MERGED" && pr.Status != "CLOSED" {
+			addf("archived pull request %q has status %q, expected MERGED or CLOSED", pr.ID, pr.Status)
+		}
+		if pr.Size != "" && !validSize(pr.Size) {
+			addf("archived pull request %q has unknown size %q", pr.ID, pr.Size)
+		}
+		seen := make(map[string]bool)
+		for _, r := range pr.Reviewers {
+			if r.UserID == "" || seen[r.UserID] {
+				addf("archived pull request %q lists reviewer %q twice or without an id", pr.ID, r.UserID)
+			}
+			seen[r.UserID] = true
+		}
+	}
+
+	scheduleIDs := make(map[string]bool)
+	pending := make(map[string]bool)
+	for _, sd := range snap.ScheduledDeactivations {
+		if users[sd.UserID] == nil {
+			addf("scheduled deactivation of unknown user %q", sd.UserID)
+		}
+		if sd.ID != "" {
+			if scheduleIDs[sd.ID] {
+				addf("duplicate scheduled deactivation %q", sd.ID)
+			}
+			scheduleIDs[sd.ID] = true
+		}
+		if sd.DeactivateAt.IsZero() {
+			addf("scheduled deactivation of user %q has no deactivate_at", sd.UserID)
+		}
+		switch sd.Status {
+		case SchedulePending:
+			if pending[sd.UserID] {
+				addf("user %q has several pending scheduled deactivations", sd.UserID)
+			}
+			pending[sd.UserID] = true
+		case ScheduleDone, ScheduleCancelled:
+		default:
+			addf("scheduled deactivation of user %q has unknown status %q", sd.UserID, sd.Status)
+		}
+	}
+
+	delegationIDs := make(map[string]bool)
+	windows := make(map[string][]SnapshotDelegation)
+	for _, d := range snap.Delegations {
+		if users[d.UserID] == nil || users[d.DelegateID] == nil {
+			addf("delegation from %q to %q names an unknown user", d.UserID, d.DelegateID)
+		}
+		if d.UserID == d.DelegateID {
+			addf("user %q delegates to themselves", d.UserID)
+		}
+		if d.ID != "" {
+			if delegationIDs[d.ID] {
+				addf("duplicate delegation %q", d.ID)
+			}
+			delegationIDs[d.ID] = true
+		}
+		if !d.EndsAt.After(d.StartsAt) {
+			addf("delegation of user %q ends before it starts", d.UserID)
+			continue
+		}
+		if d.CancelledAt != nil {
+			continue
+		}
+		for _, other := range windows[d.UserID] {
+			if d.StartsAt.Before(other.EndsAt) && other.StartsAt.Before(d.EndsAt) {
+				addf("user %q has overlapping delegations", d.UserID)
+				break
+			}
+		}
+		windows[d.UserID] = append(windows[d.UserID], d)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidSnapshot, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// snapshotRecord is one line of the NDJSON format. The first line is the
+// header with the version, followed by one line per team, user, PR,
+// archived PR, scheduled deactivation and delegation.
+type snapshotRecord struct {
+	Type       string               `json:"type"`
+	Version    int                  `json:"version,omitempty"`
+	ExportedAt *time.Time           `json:"exported_at,omitempty"`
+	Team       *SnapshotTeam        `json:"team,omitempty"`
+	User       *SnapshotUser        `json:"user,omitempty"`
+	PR         *SnapshotPullRequest `json:"pull_request,omitempty"`
+	Schedule   *SnapshotSchedule    `json:"scheduled_deactivation,omitempty"`
+	Delegation *SnapshotDelegation  `json:"delegation,omitempty"`
+}
+
+// WriteNDJSON writes the snapshot as newline-delimited JSON.
+func (snap *Snapshot) WriteNDJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	if err := enc.Encode(snapshotRecord{Type: "header", Version: snap.Version, ExportedAt: &snap.ExportedAt}); err != nil {
+		return err
+	}
+	for i := range snap.Teams {
+		if err := enc.Encode(snapshotRecord{Type: "team", Team: &snap.Teams[i]}); err != nil {
+			return err
+		}
+	}
+	for i := range snap.Users {
+		if err := enc.Encode(snapshotRecord{Type: "user", User: &snap.Users[i]}); err != nil {
+			return err
+		}
+	}
+	for i := range snap.PullRequests {
+		if err := enc.Encode(snapshotRecord{Type: "pull_request", PR: &snap.PullRequests[i]}); err != nil {
+			return err
+		}
+	}
+	for i := range snap.ArchivedPullRequests {
+		if err := enc.Encode(snapshotRecord{Type: "archived_pull_request", PR: &snap.ArchivedPullRequests[i]}); err != nil {
+			return err
+		}
+	}
+	for i := range snap.ScheduledDeactivations {
+		if err := enc.Encode(snapshotRecord{Type: "scheduled_deactivation", Schedule: &snap.ScheduledDeactivations[i]}); err != nil {
+			return err
+		}
+	}
+	for i := range snap.Delegations {
+		if err := enc.Encode(snapshotRecord{Type: "delegation", Delegation: &snap.Delegations[i]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadSnapshotNDJSON parses a snapshot written by WriteNDJSON. Format errors
+// are reported as ErrInvalidSnapshot with the line number.
+func ReadSnapshotNDJSON(r io.Reader) (*Snapshot, error) {
+	snap := &Snapshot{}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		if strings.TrimSpace(sc.Text()) == "" {
+			continue
+		}
+		var rec snapshotRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidSnapshot, line, err)
+		}
+		switch {
+		case rec.Type == "header" && line == 1:
+			snap.Version = rec.Version
+			if rec.ExportedAt != nil {
+				snap.ExportedAt = *rec.ExportedAt
+			}
+		case line == 1:
+			return nil, fmt.Errorf("%w: line 1 must be the header", ErrInvalidSnapshot)
+		case rec.Type == "team" && rec.Team != nil:
+			snap.Teams = append(snap.Teams, *rec.Team)
+		case rec.Type == "user" && rec.User != nil:
+			snap.Users = append(snap.Users, *rec.User)
+		case rec.Type == "pull_request" && rec.PR != nil:
+			snap.PullRequests = append(snap.PullRequests, *rec.PR)
+		case rec.Type == "archived_pull_request" && rec.PR != nil:
+			snap.ArchivedPullRequests = append(snap.ArchivedPullRequests, *rec.PR)
+		case rec.Type == "scheduled_deactivation" && rec.Schedule != nil:
+			snap.ScheduledDeactivations = append(snap.ScheduledDeactivations, *rec.Schedule)
+		case rec.Type == "delegation" && rec.Delegation != nil:
+			snap.Delegations = append(snap.Delegations, *rec.Delegation)
+		default:
+			return nil, fmt.Errorf("%w: line %d: unexpected record %q", ErrInvalidSnapshot, line, rec.Type)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return snap, nil
+}
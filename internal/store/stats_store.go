@@ -5,34 +5,80 @@ import (
 )
 
 type Stats struct {
-	TotalTeams      int            `json:"total_teams"`
-	TotalUsers      int            `json:"total_users"`
-	ActiveUsers     int            `json:"active_users"`
-	TotalPRs        int            `json:"total_prs"`
-	OpenPRs         int            `json:"open_prs"`
-	BusiestReviewer string         `json:"busiest_reviewer"`
-	ReviewerCounts  map[string]int `json:"reviewer_counts"`
+	TotalTeams       int            `json:"total_teams"`
+	TotalUsers       int            `json:"total_users"`
+	ActiveUsers      int            `json:"active_users"`
+	TotalMemberships int            `json:"total_memberships"`
+	MultiTeamUsers   int            `json:"multi_team_users"`
+	TotalPRs         int            `json:"total_prs"`
+	OpenPRs          int            `json:"open_prs"`
+	BusiestReviewer  string         `json:"busiest_reviewer"`
+	ReviewerCounts   map[string]int `json:"reviewer_counts"`
+	PRsBySize        map[string]int `json:"prs_by_size"`
+	ArchivedPRs      *int           `json:"archived_prs,omitempty"`
 }
 
+// GetSystemStats aggregates over live data only.
 func (s *Store) GetSystemStats(ctx context.Context) (*Stats, error) {
+	return s.systemStats(ctx, false)
+}
+
+// GetHistoricalStats is like GetSystemStats but also counts archived PRs and
+// their reviews.
+func (s *Store) GetHistoricalStats(ctx context.Context) (*Stats, error) {
+	return s.systemStats(ctx, true)
+}
+
+func (s *Store) systemStats(ctx context.Context, includeArchived bool) (*Stats, error) {
 	stats := &Stats{
 		ReviewerCounts: make(map[string]int),
+		PRsBySize:      make(map[string]int),
+	}
+
+	prs, reviews := "pull_requests", "reviewers"
+	if includeArchived {
+		prs = "(SELECT status, size FROM pull_requests UNION ALL SELECT status, size FROM pull_requests_archive)"
+		reviews = "(SELECT pull_request_id, user_id FROM reviewers UNION ALL SELECT pull_request_id, user_id FROM reviewers_archive)"
+
+		var archived int
+		err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM pull_requests_archive").Scan(&archived)
+		if err != nil { return nil, err }
+		stats.ArchivedPRs = &archived
 	}
 
-	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM teams").Scan(&stats.TotalTeams)
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM teams WHERE archived_at IS NULL").Scan(&stats.TotalTeams)
 	if err != nil { return nil, err }
 
-	err = s.db.QueryRowContext(ctx, "SELECT COUNT(*), COUNT(*) FILTER (WHERE is_active) FROM users").
+	err = s.db.QueryRowContext(ctx, "SELECT COUNT(*), COUNT(*) FILTER (WHERE is_active) FROM users WHERE deleted_at IS NULL").
 		Scan(&stats.TotalUsers, &stats.ActiveUsers)
 	if err != nil { return nil, err }
 
-	err = s.db.QueryRowContext(ctx, "SELECT COUNT(*), COUNT(*) FILTER (WHERE status = 'OPEN') FROM pull_requests").
+	err = s.db.QueryRowContext(ctx, `
+		SELECT (SELECT COUNT(*) FROM team_memberships),
+		       (SELECT COUNT(*) FROM (SELECT user_id FROM team_memberships GROUP BY user_id HAVING COUNT(*) > 1) m)
+	`).Scan(&stats.TotalMemberships, &stats.MultiTeamUsers)
+	if err != nil { return nil, err }
+
+	err = s.db.QueryRowContext(ctx, "SELECT COUNT(*), COUNT(*) FILTER (WHERE status = 'OPEN') FROM "+prs+" p").
 		Scan(&stats.TotalPRs, &stats.OpenPRs)
 	if err != nil { return nil, err }
 
+	sizeRows, err := s.db.QueryContext(ctx, "SELECT COALESCE(size, 'UNKNOWN'), COUNT(*) FROM "+prs+" p GROUP BY 1")
+	if err != nil { return nil, err }
+	for sizeRows.Next() {
+		var size string
+		var count int
+		if err := sizeRows.Scan(&size, &count); err != nil {
+			sizeRows.Close()
+			return nil, err
+		}
+		stats.PRsBySize[size] = count
+	}
+	sizeRows.Close()
+
 	rows, err := s.db.QueryContext(ctx, `
 		SELECT u.username, COUNT(r.pull_request_id) as cnt
-		FROM reviewers r
+		FROM `+reviews+` r
 		JOIN users u ON r.user_id = u.id
 		GROUP BY u.username
 		ORDER BY cnt DESC
@@ -1,23 +1,52 @@
 package store
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"sync/atomic"
 )
 
 var (
-	ErrTeamExists    = errors.New("team_name already exists")
-	ErrNotFound      = errors.New("resource not found")
-	ErrPRExists      = errors.New("pull_request_id already exists")
-	ErrPRMerged      = errors.New("cannot reassign on merged PR")
-	ErrNotAssigned   = errors.New("reviewer is not assigned to this PR")
-	ErrNoCandidate   = errors.New("no active replacement candidate in team")
+	ErrTeamExists      = errors.New("team_name already exists")
+	ErrNotFound        = errors.New("resource not found")
+	ErrPRExists        = errors.New("pull_request_id already exists")
+	ErrPRMerged        = errors.New("cannot reassign on merged PR")
+	ErrNotAssigned     = errors.New("reviewer is not assigned to this PR")
+	ErrNoCandidate     = errors.New("no active replacement candidate in team")
+	ErrInvalidPolicy   = errors.New("invalid team policy")
+	ErrParentNotFound  = errors.New("parent PR not found")
+	ErrParentNotMerged = errors.New("parent PR is not merged")
+	ErrAlreadyInTeam   = errors.New("user is already in this team")
+	ErrPRClosed        = errors.New("PR is closed")
+	ErrTeamArchived    = errors.New("team is archived")
+	ErrTeamNotFound    = errors.New("team not found")
+	ErrTeamCycle       = errors.New("team would become its own ancestor")
+	ErrUserNotFound    = errors.New("user not found")
+	ErrProfileTaken    = errors.New("email or handle is already in use")
+	ErrJobFinished     = errors.New("job has already finished")
+	ErrInvalidSnapshot = errors.New("invalid snapshot")
+	ErrNotEmpty        = errors.New("database is not empty")
+	ErrInvalidOrgChart = errors.New("invalid org chart")
+	ErrScheduleClosed  = errors.New("scheduled deactivation has already run or been cancelled")
+	ErrDelegationClash = errors.New("user already has a delegation in this window")
+	ErrDelegationEnded = errors.New("delegation has already expired or been cancelled")
+	ErrLeadsRequired   = errors.New("team with leads_only_management must keep at least one lead")
 )
 
+// querier is satisfied by both *sql.DB and *sql.Tx, so helpers can run
+// inside or outside a transaction.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
 type Store struct {
-	db *sql.DB
+	db         *sql.DB
+	policyFile atomic.Pointer[PolicyFile]
 }
 
 func New(db *sql.DB) *Store {
 	return &Store{db: db}
-}
\ No newline at end of file
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgconn"
 	"pr-reviewer/internal/model"
@@ -17,36 +18,249 @@ func (s *Store) CreateTeam(ctx context.Context, team *model.Team) error {
 	}
 	defer tx.Rollback()
 
-	_, err = tx.ExecContext(ctx, "INSERT INTO teams (name) VALUES ($1)", team.TeamName)
+	_, err = tx.ExecContext(ctx, "INSERT INTO teams (name, parent_team) VALUES ($1, NULLIF($2, ''))", team.TeamName, team.ParentTeam)
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgErr.Code == "23505" { 
 			return ErrTeamExists
 		}
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			return ErrTeamNotFound
+		}
 		return err
 	}
 
-	query := `
-		INSERT INTO users (id, username, team_name, is_active)
-		VALUES ($1, $2, $3, $4)
+	if err := upsertMembers(ctx, tx, team.TeamName, team.Members); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// upsertMembers creates or updates the given users and makes them members of
+// teamName. is_active sets the membership flag; it also sets the user's own
+// flag unless the user's primary team is a different one. Users without a
+// team get teamName as their primary team, others keep theirs. Deleted users
+// are restored.
+func upsertMembers(ctx context.Context, tx *sql.Tx, teamName string, members []model.TeamMember) error {
+	userQuery := `
+		INSERT INTO users (id, username, team_name, is_active, is_senior)
+		VALUES ($1, $2, $3, $4, $5)
 		ON CONFLICT (id) DO UPDATE SET
 			username = EXCLUDED.username,
-			team_name = EXCLUDED.team_name,
-			is_active = EXCLUDED.is_active
+			team_name = COALESCE(users.team_name, EXCLUDED.team_name),
+			is_active = CASE
+				WHEN users.team_name IS NULL OR users.team_name = EXCLUDED.team_name THEN EXCLUDED.is_active
+				ELSE users.is_active
+			END,
+			is_senior = EXCLUDED.is_senior,
+			deleted_at = NULL
+	`
+	membershipQuery := `
+		INSERT INTO team_memberships (team_name, user_id, is_active)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (team_name, user_id) DO UPDATE SET is_active = EXCLUDED.is_active
 	`
-	for _, m := range team.Members {
-		_, err := tx.ExecContext(ctx, query, m.UserID, m.Username, team.TeamName, m.IsActive)
+	for _, m := range members {
+		_, err := tx.ExecContext(ctx, userQuery, m.UserID, m.Username, teamName, m.IsActive, m.IsSenior)
 		if err != nil {
 			return fmt.Errorf("failed to upsert user %s: %w", m.UserID, err)
 		}
+		_, err = tx.ExecContext(ctx, membershipQuery, teamName, m.UserID, m.IsActive)
+		if err != nil {
+			return fmt.Errorf("failed to add membership for %s: %w", m.UserID, err)
+		}
 	}
+	return nil
+}
 
-	return tx.Commit()
+// dropMemberships removes userIDs from teamName. Users whose primary team it
+// was fall back to their oldest remaining membership, or to no team at all.
+func dropMemberships(ctx context.Context, q querier, teamName string, userIDs []string) error {
+	_, err := q.ExecContext(ctx, "DELETE FROM team_memberships WHERE team_name = $1 AND user_id = ANY($2)", teamName, userIDs)
+	if err != nil {
+		return err
+	}
+	_, err = q.ExecContext(ctx, `
+		UPDATE users u SET team_name = (
+			SELECT m.team_name FROM team_memberships m
+			WHERE m.user_id = u.id
+			ORDER BY m.created_at, m.team_name
+			LIMIT 1
+		)
+		WHERE u.id = ANY($2) AND u.team_name = $1
+	`, teamName, userIDs)
+	return err
+}
+
+// SetMemberActive toggles a single membership. A user counts as an active
+// member of the team only if both the user and the membership are active.
+func (s *Store) SetMemberActive(ctx context.Context, teamName, userID string, isActive bool) (*model.TeamMember, error) {
+	var m model.TeamMember
+	err := s.db.QueryRowContext(ctx, `
+		WITH m AS (
+			UPDATE team_memberships SET is_active = $3
+			WHERE team_name = $1 AND user_id = $2
+			RETURNING user_id, is_active
+		)
+		SELECT u.id, u.username, u.is_active AND m.is_active, u.is_senior, COALESCE(u.team_name = $1, false)
+		FROM m JOIN users u ON u.id = m.user_id
+	`, teamName, userID, isActive).Scan(&m.UserID, &m.Username, &m.IsActive, &m.IsSenior, &m.IsPrimary)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// AddTeamMembers adds members to an existing team.
+func (s *Store) AddTeamMembers(ctx context.Context, teamName string, members []model.TeamMember) (*model.Team, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockTeam(ctx, tx, teamName); err != nil {
+		return nil, err
+	}
+	if err := upsertMembers(ctx, tx, teamName, members); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetTeam(ctx, teamName)
+}
+
+type RemoveMembersResult struct {
+	Removed       []string            `json:"removed"`
+	Reassignments map[string][]string `json:"reassignments"`
+}
+
+// RemoveTeamMembers takes users out of teamName. Their open reviews on the
+// team's PRs are reassigned to the remaining members in the same transaction;
+// reviews for their other teams are kept. Ids that are not members of the team
+// are ignored.
+func (s *Store) RemoveTeamMembers(ctx context.Context, teamName string, userIDs []string) (*RemoveMembersResult, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockTeam(ctx, tx, teamName); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx,
+		"SELECT user_id FROM team_memberships WHERE team_name = $1 AND user_id = ANY($2) FOR UPDATE", teamName, userIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	removed := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		removed = append(removed, id)
+	}
+	rows.Close()
+
+	tasks, err := s.openAssignments(ctx, tx, removed, teamName)
+	if err != nil {
+		return nil, err
+	}
+	moves, _, err := s.replaceReviewers(ctx, tx, tasks, removed)
+	if err != nil {
+		return nil, err
+	}
+	reassignments := reassignmentsByPR(moves)
+
+	if err := dropMemberships(ctx, tx, teamName, removed); err != nil {
+		return nil, err
+	}
+
+	return &RemoveMembersResult{Removed: removed, Reassignments: reassignments}, tx.Commit()
+}
+
+// SetTeamParent makes parent the parent team of teamName, or clears the parent
+// when it is empty. A team cannot become its own ancestor.
+func (s *Store) SetTeamParent(ctx context.Context, teamName, parent string) (*model.Team, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := lockTeam(ctx, tx, teamName); err != nil {
+		return nil, err
+	}
+	if parent != "" {
+		if err := lockTeam(ctx, tx, parent); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return nil, ErrTeamNotFound
+			}
+			return nil, err
+		}
+
+		var cycle bool
+		err = tx.QueryRowContext(ctx, `
+			WITH RECURSIVE up AS (
+				SELECT name, parent_team FROM teams WHERE name = $1
+				UNION
+				SELECT t.name, t.parent_team FROM teams t JOIN up ON t.name = up.parent_team
+			)
+			SELECT EXISTS(SELECT 1 FROM up WHERE name = $2)
+		`, parent, teamName).Scan(&cycle)
+		if err != nil {
+			return nil, err
+		}
+		if cycle {
+			return nil, ErrTeamCycle
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE teams SET parent_team = NULLIF($2, '') WHERE name = $1", teamName, parent)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetTeam(ctx, teamName)
+}
+
+// lockTeam locks the team row for the rest of the transaction. It returns
+// ErrNotFound if there is no such team and ErrTeamArchived if it is archived.
+func lockTeam(ctx context.Context, tx *sql.Tx, teamName string) error {
+	var archivedAt sql.NullTime
+	err := tx.QueryRowContext(ctx, "SELECT archived_at FROM teams WHERE name = $1 FOR UPDATE", teamName).Scan(&archivedAt)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if archivedAt.Valid {
+		return ErrTeamArchived
+	}
+	return nil
 }
 
 func (s *Store) GetTeam(ctx context.Context, teamName string) (*model.Team, error) {
-	var name string
-	err := s.db.QueryRowContext(ctx, "SELECT name FROM teams WHERE name = $1", teamName).Scan(&name)
+	var name, parent string
+	var archivedAt *time.Time
+	err := s.db.QueryRowContext(ctx,
+		"SELECT name, COALESCE(parent_team, ''), archived_at FROM teams WHERE name = $1", teamName,
+	).Scan(&name, &parent, &archivedAt)
 	if err == sql.ErrNoRows {
 		return nil, ErrNotFound
 	}
@@ -54,7 +268,24 @@ func (s *Store) GetTeam(ctx context.Context, teamName string) (*model.Team, erro
 		return nil, err
 	}
 
-	rows, err := s.db.QueryContext(ctx, "SELECT id, username, is_active FROM users WHERE team_name = $1", teamName)
+	children, err := queryIDs(ctx, s.db,
+		"SELECT name FROM teams WHERE parent_team = $1 AND archived_at IS NULL ORDER BY name", teamName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	leads, err := teamLeads(ctx, s.db, teamName)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT u.id, u.username, u.is_active AND m.is_active, u.is_senior, COALESCE(u.team_name = m.team_name, false)
+		FROM team_memberships m
+		JOIN users u ON m.user_id = u.id
+		WHERE m.team_name = $1
+		ORDER BY u.id
+	`, teamName)
 	if err != nil {
 		return nil, err
 	}
@@ -63,105 +294,554 @@ func (s *Store) GetTeam(ctx context.Context, teamName string) (*model.Team, erro
 	var members []model.TeamMember
 	for rows.Next() {
 		var m model.TeamMember
-		if err := rows.Scan(&m.UserID, &m.Username, &m.IsActive); err != nil {
+		if err := rows.Scan(&m.UserID, &m.Username, &m.IsActive, &m.IsSenior, &m.IsPrimary); err != nil {
 			return nil, err
 		}
 		members = append(members, m)
 	}
 
 	return &model.Team{
-		TeamName: teamName,
-		Members:  members,
+		TeamName:   teamName,
+		ParentTeam: parent,
+		ChildTeams: children,
+		Leads:      leads,
+		Members:    members,
+		ArchivedAt: archivedAt,
 	}, nil
 }
 
-func (s *Store) BulkDeactivateAndReassign(ctx context.Context, userIDs []string) (map[string][]string, error) {
+// ListTeams returns every team with its member counts and open review load.
+// The load counts the OPEN PRs belonging to the team and the reviews on them.
+// Archived teams are included only if includeArchived is set.
+func (s *Store) ListTeams(ctx context.Context, includeArchived bool) ([]model.TeamSummary, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT t.name,
+		       COALESCE(t.parent_team, ''),
+		       COUNT(m.user_id),
+		       COUNT(m.user_id) FILTER (WHERE m.is_active AND u.is_active),
+		       (SELECT COUNT(*) FROM pull_requests p
+		        JOIN users a ON p.author_id = a.id
+		        WHERE COALESCE(p.team_name, a.team_name) = t.name AND p.status = 'OPEN'),
+		       (SELECT COUNT(*) FROM reviewers r
+		        JOIN pull_requests p ON r.pull_request_id = p.id
+		        JOIN users a ON p.author_id = a.id
+		        WHERE COALESCE(p.team_name, a.team_name) = t.name AND p.status = 'OPEN'),
+		       t.archived_at
+		FROM teams t
+		LEFT JOIN team_memberships m ON m.team_name = t.name
+		LEFT JOIN users u ON m.user_id = u.id
+		WHERE $1 OR t.archived_at IS NULL
+		GROUP BY t.name
+		ORDER BY t.name
+	`, includeArchived)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	teams := []model.TeamSummary{}
+	for rows.Next() {
+		var t model.TeamSummary
+		err := rows.Scan(&t.TeamName, &t.ParentTeam, &t.MemberCount, &t.ActiveMemberCount, &t.OpenPRsAuthored, &t.OpenReviews, &t.ArchivedAt)
+		if err != nil {
+			return nil, err
+		}
+		teams = append(teams, t)
+	}
+	return teams, rows.Err()
+}
+
+const (
+	DeactivationDeactivated     = "DEACTIVATED"
+	DeactivationAlreadyInactive = "ALREADY_INACTIVE"
+	DeactivationUnknownUser     = "UNKNOWN_USER"
+
+	OutcomeReassigned  = "REASSIGNED"
+	OutcomeNoCandidate = "NO_CANDIDATE"
+)
+
+// ReviewOutcome tells what happened to one review of a deactivated user.
+type ReviewOutcome struct {
+	PullRequestID string `json:"pull_request_id"`
+	ReviewerID    string `json:"reviewer_id"`
+	Outcome       string `json:"outcome"`
+	NewReviewerID string `json:"new_reviewer_id,omitempty"`
+}
+
+type UserDeactivation struct {
+	UserID  string          `json:"user_id"`
+	Status  string          `json:"status"`
+	Reviews []ReviewOutcome `json:"reviews"`
+}
+
+type PullRequestOutcome struct {
+	PullRequestID string          `json:"pull_request_id"`
+	Reviews       []ReviewOutcome `json:"reviews"`
+}
+
+// DeactivationReport describes a bulk deactivation per user and per PR.
+type DeactivationReport struct {
+	Deactivated      []string             `json:"deactivated"`
+	DeactivatedCount int                  `json:"deactivated_count"`
+	Users            []UserDeactivation   `json:"users"`
+	PullRequests     []PullRequestOutcome `json:"pull_requests"`
+	Reassignments    map[string][]string  `json:"reassignments"`
+	LeadAlerts       []LeadAlert          `json:"lead_alerts"`
+}
+
+// Merge adds the outcome of another deactivation, such as the next chunk of a
+// bulk job, to the report.
+func (r *DeactivationReport) Merge(other *DeactivationReport) {
+	r.Deactivated = append(r.Deactivated, other.Deactivated...)
+	r.DeactivatedCount += other.DeactivatedCount
+	r.Users = append(r.Users, other.Users...)
+	r.LeadAlerts = append(r.LeadAlerts, other.LeadAlerts...)
+
+	for _, pr := range other.PullRequests {
+		merged := false
+		for i := range r.PullRequests {
+			if r.PullRequests[i].PullRequestID == pr.PullRequestID {
+				r.PullRequests[i].Reviews = append(r.PullRequests[i].Reviews, pr.Reviews...)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			r.PullRequests = append(r.PullRequests, pr)
+		}
+	}
+
+	if r.Reassignments == nil {
+		r.Reassignments = make(map[string][]string)
+	}
+	for prID, ids := range other.Reassignments {
+		r.Reassignments[prID] = append(r.Reassignments[prID], ids...)
+	}
+}
+
+// BulkDeactivateAndReassign deactivates the users and reassigns their reviews
+// on OPEN PRs in a single transaction. Reviews nobody could take over stay in
+// place, are reported as NO_CANDIDATE and become alerts for the leads of the
+// PRs' teams. Unknown and deleted ids are reported and otherwise ignored.
+func (s *Store) BulkDeactivateAndReassign(ctx context.Context, userIDs []string) (*DeactivationReport, error) {
 	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
-	queryDeactivate := `
-		UPDATE users 
-		SET is_active = false 
-		WHERE id = ANY($1)
-		RETURNING id, team_name
-	`
-	rows, err := tx.QueryContext(ctx, queryDeactivate, userIDs)
+	report, err := s.deactivateUsers(ctx, tx, userIDs, nil)
 	if err != nil {
 		return nil, err
 	}
-	rows.Close() 
+	return report, tx.Commit()
+}
 
-	queryFindAssignments := `
-		SELECT r.pull_request_id, r.user_id, p.author_id, u.team_name
-		FROM reviewers r
-		JOIN pull_requests p ON r.pull_request_id = p.id
-		JOIN users u ON r.user_id = u.id
-		WHERE r.user_id = ANY($1) AND p.status = 'OPEN'
-	`
-	rows, err = tx.QueryContext(ctx, queryFindAssignments, userIDs)
+// deactivateUsers deactivates userIDs and reassigns their reviews, never to
+// anyone in exclude.
+func (s *Store) deactivateUsers(ctx context.Context, tx *sql.Tx, userIDs, exclude []string) (*DeactivationReport, error) {
+	rows, err := tx.QueryContext(ctx,
+		"SELECT id, is_active FROM users WHERE id = ANY($1) AND deleted_at IS NULL FOR UPDATE", userIDs,
+	)
 	if err != nil {
 		return nil, err
 	}
+	wasActive := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		var active bool
+		if err := rows.Scan(&id, &active); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		wasActive[id] = active
+	}
+	rows.Close()
 
-	type Assignment struct {
-		PrID     string
-		OldUser  string
-		AuthorID string
-		TeamName string
+	report := &DeactivationReport{
+		Deactivated:  []string{},
+		Users:        []UserDeactivation{},
+		PullRequests: []PullRequestOutcome{},
 	}
-	var tasks []Assignment
+	known := []string{}
+	userIndex := make(map[string]int)
+	for _, id := range userIDs {
+		if _, seen := userIndex[id]; seen {
+			continue
+		}
+		u := UserDeactivation{UserID: id, Status: DeactivationUnknownUser, Reviews: []ReviewOutcome{}}
+		if active, ok := wasActive[id]; ok {
+			known = append(known, id)
+			u.Status = DeactivationAlreadyInactive
+			if active {
+				u.Status = DeactivationDeactivated
+				report.Deactivated = append(report.Deactivated, id)
+			}
+		}
+		userIndex[id] = len(report.Users)
+		report.Users = append(report.Users, u)
+	}
+	report.DeactivatedCount = len(report.Deactivated)
+
+	_, err = tx.ExecContext(ctx, "UPDATE users SET is_active = false WHERE id = ANY($1)", report.Deactivated)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := s.openAssignments(ctx, tx, known, "")
+	if err != nil {
+		return nil, err
+	}
+	moves, unresolved, err := s.replaceReviewers(ctx, tx, tasks, exclude)
+	if err != nil {
+		return nil, err
+	}
+	report.Reassignments = reassignmentsByPR(moves)
+	report.LeadAlerts, err = s.leadAlerts(ctx, tx, unresolved)
+	if err != nil {
+		return nil, err
+	}
+
+	newReviewer := make(map[ReviewMove]string)
+	for _, m := range moves {
+		newReviewer[ReviewMove{PullRequestID: m.PullRequestID, FromUserID: m.FromUserID}] = m.ToUserID
+	}
+	prIndex := make(map[string]int)
+	for _, task := range tasks {
+		outcome := ReviewOutcome{PullRequestID: task.PrID, ReviewerID: task.OldUser, Outcome: OutcomeNoCandidate}
+		if to, ok := newReviewer[ReviewMove{PullRequestID: task.PrID, FromUserID: task.OldUser}]; ok {
+			outcome.Outcome = OutcomeReassigned
+			outcome.NewReviewerID = to
+		}
+
+		u := &report.Users[userIndex[task.OldUser]]
+		u.Reviews = append(u.Reviews, outcome)
+
+		i, ok := prIndex[task.PrID]
+		if !ok {
+			i = len(report.PullRequests)
+			prIndex[task.PrID] = i
+			report.PullRequests = append(report.PullRequests, PullRequestOutcome{PullRequestID: task.PrID})
+		}
+		report.PullRequests[i].Reviews = append(report.PullRequests[i].Reviews, outcome)
+	}
+	return report, nil
+}
+
+const (
+	ReactivationReactivated   = "REACTIVATED"
+	ReactivationAlreadyActive = "ALREADY_ACTIVE"
+	ReactivationUnknownUser   = "UNKNOWN_USER"
+)
+
+type ReactivationReport struct {
+	UserID    string       `json:"user_id"`
+	Status    string       `json:"status"`
+	TakenOver []ReviewMove `json:"taken_over"`
+}
+
+// BulkReactivate activates the users in a single transaction. With rebalance,
+// every reactivated user takes reviews over from the most loaded members of
+// each team they belong to, until they are within one review of them. The
+// report follows the order of userIDs.
+func (s *Store) BulkReactivate(ctx context.Context, userIDs []string, rebalance bool) ([]ReactivationReport, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	reports, err := s.reactivateUsers(ctx, tx, userIDs, rebalance)
+	if err != nil {
+		return nil, err
+	}
+	return reports, tx.Commit()
+}
+
+func (s *Store) reactivateUsers(ctx context.Context, tx *sql.Tx, userIDs []string, rebalance bool) ([]ReactivationReport, error) {
+	rows, err := tx.QueryContext(ctx,
+		"SELECT id, is_active FROM users WHERE id = ANY($1) AND deleted_at IS NULL FOR UPDATE", userIDs,
+	)
+	if err != nil {
+		return nil, err
+	}
+	wasActive := make(map[string]bool)
 	for rows.Next() {
-		var a Assignment
-		if err := rows.Scan(&a.PrID, &a.OldUser, &a.AuthorID, &a.TeamName); err != nil {
+		var id string
+		var active bool
+		if err := rows.Scan(&id, &active); err != nil {
+			rows.Close()
 			return nil, err
 		}
-		tasks = append(tasks, a)
+		wasActive[id] = active
 	}
 	rows.Close()
 
-	reassignments := make(map[string][]string)
+	reports := make([]ReactivationReport, 0, len(userIDs))
+	byUser := make(map[string]*ReactivationReport)
+	reactivated := []string{}
+	for _, id := range userIDs {
+		if _, seen := byUser[id]; seen {
+			continue
+		}
+		report := ReactivationReport{UserID: id, Status: ReactivationUnknownUser, TakenOver: []ReviewMove{}}
+		if active, known := wasActive[id]; known {
+			report.Status = ReactivationAlreadyActive
+			if !active {
+				report.Status = ReactivationReactivated
+				reactivated = append(reactivated, id)
+			}
+		}
+		reports = append(reports, report)
+		byUser[id] = &reports[len(reports)-1]
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE users SET is_active = true WHERE id = ANY($1)", reactivated)
+	if err != nil {
+		return nil, err
+	}
+
+	if rebalance && len(reactivated) > 0 {
+		teams, err := queryIDs(ctx, tx, `
+			SELECT DISTINCT team_name FROM team_memberships
+			WHERE user_id = ANY($1) AND is_active = true
+			ORDER BY team_name
+		`, reactivated)
+		if err != nil {
+			return nil, err
+		}
+		receivers := make(map[string]bool)
+		for _, id := range reactivated {
+			receivers[id] = true
+		}
+		for _, team := range teams {
+			w, err := s.loadTeamWorkload(ctx, tx, team)
+			if err != nil {
+				return nil, err
+			}
+			moves := w.plan(1, receivers)
+			if err := s.applyMoves(ctx, tx, team, moves); err != nil {
+				return nil, err
+			}
+			for _, m := range moves {
+				byUser[m.ToUserID].TakenOver = append(byUser[m.ToUserID].TakenOver, m)
+			}
+		}
+	}
+
+	return reports, nil
+}
+
+const (
+	TeamRemovalArchive = "ARCHIVE"
+	TeamRemovalDelete  = "DELETE"
+
+	MembersDeactivate = "DEACTIVATE"
+	MembersMove       = "MOVE"
 
-	stmtCandidate, err := tx.PrepareContext(ctx, `
-		SELECT id FROM users 
-		WHERE team_name = $1 
-		  AND is_active = true 
-		  AND id != $2 
-		  AND id NOT IN (SELECT user_id FROM reviewers WHERE pull_request_id = $3)
-		ORDER BY RANDOM() LIMIT 1
-	`)
+	OpenPRsClose    = "CLOSE"
+	OpenPRsReassign = "REASSIGN"
+)
+
+type TeamRemoval struct {
+	TeamName     string `json:"team_name"`
+	Mode         string `json:"mode"`
+	MemberAction string `json:"member_action"`
+	PRAction     string `json:"pr_action"`
+	TargetTeam   string `json:"target_team,omitempty"`
+}
+
+type TeamRemovalResult struct {
+	TeamName      string              `json:"team_name"`
+	Mode          string              `json:"mode"`
+	Members       []string            `json:"members"`
+	Deactivated   []string            `json:"deactivated"`
+	ClosedPRs     []string            `json:"closed_prs"`
+	Reassignments map[string][]string `json:"reassignments"`
+}
+
+// RemoveTeam archives or deletes a team in a single transaction. The team's
+// OPEN PRs are closed or handed to the target team, and its memberships are
+// dropped or moved to the target team. With MembersDeactivate only members
+// without another team are deactivated; their reviews for other teams are
+// reassigned first.
+func (s *Store) RemoveTeam(ctx context.Context, req TeamRemoval) (*TeamRemovalResult, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
-	defer stmtCandidate.Close()
+	defer tx.Rollback()
 
-	stmtSwap, err := tx.PrepareContext(ctx, `
-		UPDATE reviewers SET user_id = $1 
-		WHERE pull_request_id = $2 AND user_id = $3
-	`)
+	if err := lockTeam(ctx, tx, req.TeamName); err != nil {
+		return nil, err
+	}
+	if req.TargetTeam != "" {
+		if err := lockTeam(ctx, tx, req.TargetTeam); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &TeamRemovalResult{
+		TeamName:      req.TeamName,
+		Mode:          req.Mode,
+		Members:       []string{},
+		Deactivated:   []string{},
+		ClosedPRs:     []string{},
+		Reassignments: map[string][]string{},
+	}
+
+	result.Members, err = queryIDs(ctx, tx,
+		"SELECT user_id FROM team_memberships WHERE team_name = $1 ORDER BY user_id FOR UPDATE", req.TeamName,
+	)
 	if err != nil {
 		return nil, err
 	}
-	defer stmtSwap.Close()
 
-	for _, task := range tasks {
-		var newReviewerID string
-		err := stmtCandidate.QueryRowContext(ctx, task.TeamName, task.AuthorID, task.PrID).Scan(&newReviewerID)
+	switch req.PRAction {
+	case OpenPRsClose:
+		result.ClosedPRs, err = queryIDs(ctx, tx, `
+			UPDATE pull_requests p SET status = 'CLOSED', closed_at = CURRENT_TIMESTAMP
+			FROM users a
+			WHERE p.author_id = a.id AND p.status = 'OPEN' AND COALESCE(p.team_name, a.team_name) = $1
+			RETURNING p.id
+		`, req.TeamName)
 		if err != nil {
-			if err == sql.ErrNoRows {
-				continue 
+			return nil, err
+		}
+
+	case OpenPRsReassign:
+		prIDs, err := queryIDs(ctx, tx, `
+			UPDATE pull_requests p SET team_name = $2
+			FROM users a
+			WHERE p.author_id = a.id AND p.status = 'OPEN' AND COALESCE(p.team_name, a.team_name) = $1
+			RETURNING p.id
+		`, req.TeamName, req.TargetTeam)
+		if err != nil {
+			return nil, err
+		}
+
+		// Reviewers who are also members of the target team keep their reviews,
+		// and so do the members moving there with the team.
+		moving := []string{}
+		if req.MemberAction == MembersMove {
+			moving = result.Members
+		}
+		rows, err := tx.QueryContext(ctx, `
+			SELECT r.pull_request_id, r.user_id, p.author_id
+			FROM reviewers r
+			JOIN pull_requests p ON r.pull_request_id = p.id
+			WHERE p.id = ANY($1)
+			  AND NOT EXISTS (SELECT 1 FROM team_memberships m WHERE m.team_name = $2 AND m.user_id = r.user_id)
+			  AND r.user_id <> ALL($3)
+		`, prIDs, req.TargetTeam, moving)
+		if err != nil {
+			return nil, err
+		}
+		var tasks []reviewAssignment
+		for rows.Next() {
+			a := reviewAssignment{TeamName: req.TargetTeam}
+			if err := rows.Scan(&a.PrID, &a.OldUser, &a.AuthorID); err != nil {
+				rows.Close()
+				return nil, err
 			}
+			tasks = append(tasks, a)
+		}
+		rows.Close()
+
+		moves, _, err := s.replaceReviewers(ctx, tx, tasks, nil)
+		if err != nil {
 			return nil, err
 		}
+		result.Reassignments = reassignmentsByPR(moves)
+	}
 
-		_, err = stmtSwap.ExecContext(ctx, newReviewerID, task.PrID, task.OldUser)
+	switch req.MemberAction {
+	case MembersDeactivate:
+		result.Deactivated, err = queryIDs(ctx, tx, `
+			SELECT m.user_id FROM team_memberships m
+			WHERE m.team_name = $1
+			  AND NOT EXISTS (SELECT 1 FROM team_memberships o WHERE o.user_id = m.user_id AND o.team_name <> $1)
+			ORDER BY m.user_id
+		`, req.TeamName)
 		if err != nil {
 			return nil, err
 		}
-		reassignments[task.PrID] = append(reassignments[task.PrID], newReviewerID)
+
+		// Reviews the deactivated members hold on other teams' PRs go back to
+		// those teams.
+		tasks, err := s.openAssignments(ctx, tx, result.Deactivated, "")
+		if err != nil {
+			return nil, err
+		}
+		moves, _, err := s.replaceReviewers(ctx, tx, tasks, result.Deactivated)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range moves {
+			result.Reassignments[m.PullRequestID] = append(result.Reassignments[m.PullRequestID], m.ToUserID)
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE users SET is_active = false WHERE id = ANY($1)", result.Deactivated)
+		if err != nil {
+			return nil, err
+		}
+
+	case MembersMove:
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO team_memberships (team_name, user_id, is_active)
+			SELECT $1::varchar, user_id, is_active FROM team_memberships WHERE team_name = $2
+			ON CONFLICT (team_name, user_id) DO NOTHING
+		`, req.TargetTeam, req.TeamName)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE users SET team_name = $1 WHERE team_name = $2", req.TargetTeam, req.TeamName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := dropMemberships(ctx, tx, req.TeamName, result.Members); err != nil {
+		return nil, err
+	}
+
+	if req.Mode == TeamRemovalDelete {
+		for _, query := range []string{
+			"UPDATE teams SET parent_team = (SELECT parent_team FROM teams WHERE name = $1) WHERE parent_team = $1",
+			"DELETE FROM team_size_rules WHERE team_name = $1",
+			"DELETE FROM team_leads WHERE team_name = $1",
+			"DELETE FROM team_policies WHERE team_name = $1",
+			"DELETE FROM teams WHERE name = $1",
+		} {
+			if _, err := tx.ExecContext(ctx, query, req.TeamName); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		_, err = tx.ExecContext(ctx, "UPDATE teams SET archived_at = CURRENT_TIMESTAMP WHERE name = $1", req.TeamName)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, tx.Commit()
+}
+
+// queryIDs runs a query returning a single text column and collects it.
+func queryIDs(ctx context.Context, q querier, query string, args ...any) ([]string, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	return reassignments, tx.Commit()
-}
\ No newline at end of file
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -3,25 +3,347 @@ package store
 import (
 	"context"
 	"database/sql"
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
 	"pr-reviewer/internal/model"
 )
 
+// userColumns is selected (or returned) by every query read by scanUser.
+const userColumns = "id, username, COALESCE(team_name, ''), is_active, is_senior, COALESCE(email, ''), COALESCE(handle, ''), deleted_at"
+
+func scanUser(row *sql.Row) (*model.User, error) {
+	var u model.User
+	err := row.Scan(&u.ID, &u.Username, &u.TeamName, &u.IsActive, &u.IsSenior, &u.Email, &u.Handle, &u.DeletedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
 func (s *Store) SetUserActive(ctx context.Context, userID string, isActive bool) (*model.User, error) {
 	query := `
 		UPDATE users 
 		SET is_active = $1 
-		WHERE id = $2 
-		RETURNING id, username, team_name, is_active
-	`
-	var u model.User
-	err := s.db.QueryRowContext(ctx, query, isActive, userID).Scan(
-		&u.ID, &u.Username, &u.TeamName, &u.IsActive,
-	)
+		WHERE id = $2 AND deleted_at IS NULL
+		RETURNING ` + userColumns
+	return scanUser(s.db.QueryRowContext(ctx, query, isActive, userID))
+}
+
+// GetUser returns a user with all their teams and their current and upcoming
+// delegations. Deleted users are returned too, with deleted_at set, so that
+// historical PRs can be resolved.
+func (s *Store) GetUser(ctx context.Context, userID string) (*model.User, error) {
+	u, err := scanUser(s.db.QueryRowContext(ctx, "SELECT "+userColumns+" FROM users WHERE id = $1", userID))
+	if err != nil {
+		return nil, err
+	}
+	u.Teams, err = queryIDs(ctx, s.db, "SELECT team_name FROM team_memberships WHERE user_id = $1 ORDER BY team_name", userID)
+	if err != nil {
+		return nil, err
+	}
+	u.Delegations, err = listDelegations(ctx, s.db, userID, false)
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// UpdateUser changes the profile of a user that is not deleted.
+func (s *Store) UpdateUser(ctx context.Context, userID string, upd model.UserUpdate) (*model.User, error) {
+	query := `
+		UPDATE users SET
+			username = COALESCE($2, username),
+			email = CASE WHEN $3::varchar IS NULL THEN email ELSE NULLIF($3, '') END,
+			handle = CASE WHEN $4::varchar IS NULL THEN handle ELSE NULLIF($4, '') END
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING ` + userColumns
+	u, err := scanUser(s.db.QueryRowContext(ctx, query, userID, upd.Username, upd.Email, upd.Handle))
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+			return nil, ErrProfileTaken
+		}
+		return nil, err
+	}
+	return u, nil
+}
+
+type DeleteUserResult struct {
+	User          *model.User         `json:"user"`
+	Reassignments map[string][]string `json:"reassignments"`
+	LeadAlerts    []LeadAlert         `json:"lead_alerts"`
+}
+
+// DeleteUser soft-deletes a user: they are deactivated and leave all their
+// teams and lead roles, while their PRs and past reviews stay in place. Their
+// reviews on OPEN PRs are reassigned; the ones without a replacement are
+// returned as lead alerts. The last lead of a team with leads_only_management
+// cannot be deleted (ErrLeadsRequired), as that would lift the restriction.
+func (s *Store) DeleteUser(ctx context.Context, userID string) (*DeleteUserResult, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	u, err := scanUser(tx.QueryRowContext(ctx, `
+		UPDATE users SET is_active = false, team_name = NULL, deleted_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND deleted_at IS NULL
+		RETURNING `+userColumns, userID))
+	if err != nil {
+		return nil, err
+	}
+
+	// The teams are locked so that two of their leads cannot be deleted
+	// at the same time.
+	led, err := queryIDs(ctx, tx, `
+		SELECT t.name FROM teams t JOIN team_leads l ON l.team_name = t.name
+		WHERE l.user_id = $1 ORDER BY t.name FOR UPDATE OF t
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	var lastLead bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM team_policies tp
+			WHERE tp.team_name = ANY($1) AND tp.leads_only_management
+			  AND NOT EXISTS (SELECT 1 FROM team_leads o WHERE o.team_name = tp.team_name AND o.user_id <> $2)
+		)
+	`, led, userID).Scan(&lastLead)
+	if err != nil {
+		return nil, err
+	}
+	if lastLead {
+		return nil, ErrLeadsRequired
+	}
+
+	ids := []string{userID}
+	tasks, err := s.openAssignments(ctx, tx, ids, "")
+	if err != nil {
+		return nil, err
+	}
+	moves, unresolved, err := s.replaceReviewers(ctx, tx, tasks, ids)
+	if err != nil {
+		return nil, err
+	}
+	alerts, err := s.leadAlerts(ctx, tx, unresolved)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, query := range []string{
+		"DELETE FROM team_memberships WHERE user_id = $1",
+		"DELETE FROM team_leads WHERE user_id = $1",
+		"UPDATE scheduled_deactivations SET status = 'CANCELLED', finished_at = CURRENT_TIMESTAMP WHERE user_id = $1 AND status = 'PENDING'",
+		"UPDATE review_delegations SET cancelled_at = CURRENT_TIMESTAMP WHERE (user_id = $1 OR delegate_id = $1) AND cancelled_at IS NULL AND ends_at > CURRENT_TIMESTAMP",
+	} {
+		if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	return &DeleteUserResult{User: u, Reassignments: reassignmentsByPR(moves), LeadAlerts: alerts}, tx.Commit()
+}
+
+const (
+	TransferKeepReviews     = "KEEP"
+	TransferReassignReviews = "REASSIGN"
+)
+
+type TransferResult struct {
+	User          *model.User         `json:"user"`
+	FromTeam      string              `json:"from_team"`
+	Reassignments map[string][]string `json:"reassignments"`
+	KeptReviews   []string            `json:"kept_reviews"`
+}
+
+// TransferUser moves a user's primary membership into toTeam; their other
+// memberships are untouched. With TransferReassignReviews their reviews on the
+// old team's OPEN PRs are handed to its remaining members; reviews without a
+// candidate, and all reviews with TransferKeepReviews, stay with the user.
+func (s *Store) TransferUser(ctx context.Context, userID, toTeam, reviewPolicy string) (*TransferResult, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var fromTeam string
+	err = tx.QueryRowContext(ctx, "SELECT COALESCE(team_name, '') FROM users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", userID).Scan(&fromTeam)
 	if err == sql.ErrNoRows {
 		return nil, ErrNotFound
 	}
 	if err != nil {
 		return nil, err
 	}
-	return &u, nil
-}
\ No newline at end of file
+	if fromTeam == toTeam {
+		return nil, ErrAlreadyInTeam
+	}
+	if err := lockTeam(ctx, tx, toTeam); err != nil {
+		return nil, err
+	}
+
+	result, err := s.transferUser(ctx, tx, userID, fromTeam, toTeam, reviewPolicy, true)
+	if err != nil {
+		return nil, err
+	}
+	return result, tx.Commit()
+}
+
+// transferUser moves the locked user from fromTeam, which may be empty, into
+// the locked toTeam. An existing membership in toTeam is activated only with
+// activate; otherwise it keeps its flag.
+func (s *Store) transferUser(ctx context.Context, tx *sql.Tx, userID, fromTeam, toTeam, reviewPolicy string, activate bool) (*TransferResult, error) {
+	var err error
+	result := &TransferResult{FromTeam: fromTeam, Reassignments: map[string][]string{}, KeptReviews: []string{}}
+
+	var tasks []reviewAssignment
+	if fromTeam != "" {
+		tasks, err = s.openAssignments(ctx, tx, []string{userID}, fromTeam)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if reviewPolicy == TransferReassignReviews {
+		moves, _, err := s.replaceReviewers(ctx, tx, tasks, []string{userID})
+		if err != nil {
+			return nil, err
+		}
+		result.Reassignments = reassignmentsByPR(moves)
+	}
+	for _, task := range tasks {
+		if _, moved := result.Reassignments[task.PrID]; !moved {
+			result.KeptReviews = append(result.KeptReviews, task.PrID)
+		}
+	}
+
+	if fromTeam != "" {
+		_, err = tx.ExecContext(ctx, "DELETE FROM team_memberships WHERE team_name = $1 AND user_id = $2", fromTeam, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO team_memberships (team_name, user_id) VALUES ($1, $2)
+		ON CONFLICT (team_name, user_id) DO UPDATE SET is_active = team_memberships.is_active OR $3
+	`, toTeam, userID, activate)
+	if err != nil {
+		return nil, err
+	}
+
+	result.User, err = scanUser(tx.QueryRowContext(ctx,
+		"UPDATE users SET team_name = $1 WHERE id = $2 RETURNING "+userColumns, toTeam, userID,
+	))
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+const (
+	HandoffNotFound    = "NOT_FOUND"
+	HandoffNotOpen     = "NOT_OPEN"
+	HandoffNotAssigned = "NOT_ASSIGNED"
+)
+
+// HandoffSkip is a requested PR whose review could not be handed off.
+type HandoffSkip struct {
+	PullRequestID string `json:"pull_request_id"`
+	Reason        string `json:"reason"`
+}
+
+type HandoffReport struct {
+	UserID          string          `json:"user_id"`
+	Reviews         []ReviewOutcome `json:"reviews"`
+	ReassignedCount int             `json:"reassigned_count"`
+	KeptCount       int             `json:"kept_count"`
+	Skipped         []HandoffSkip   `json:"skipped"`
+}
+
+// HandoffReviews hands the user's reviews on OPEN PRs to other members of
+// each PR's team, drawn like ReassignReviewer draws them. With prIDs only
+// those PRs are handed off; the ones the user does not review on an OPEN PR
+// are reported as skipped. Reviews without a candidate stay with the user.
+// The user stays active and eligible for new PRs.
+func (s *Store) HandoffReviews(ctx context.Context, userID string, prIDs []string) (*HandoffReport, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, "SELECT true FROM users WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", userID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := s.openAssignments(ctx, tx, []string{userID}, "")
+	if err != nil {
+		return nil, err
+	}
+	report := &HandoffReport{UserID: userID, Reviews: []ReviewOutcome{}, Skipped: []HandoffSkip{}}
+	if len(prIDs) > 0 {
+		held := make(map[string]reviewAssignment, len(tasks))
+		for _, task := range tasks {
+			held[task.PrID] = task
+		}
+		tasks = nil
+		for _, prID := range uniqueIDs(prIDs) {
+			if task, ok := held[prID]; ok {
+				tasks = append(tasks, task)
+				continue
+			}
+			reason, err := handoffSkipReason(ctx, tx, prID)
+			if err != nil {
+				return nil, err
+			}
+			report.Skipped = append(report.Skipped, HandoffSkip{PullRequestID: prID, Reason: reason})
+		}
+	}
+
+	moves, _, err := s.replaceReviewers(ctx, tx, tasks, []string{userID})
+	if err != nil {
+		return nil, err
+	}
+	newReviewer := make(map[string]string, len(moves))
+	for _, m := range moves {
+		newReviewer[m.PullRequestID] = m.ToUserID
+	}
+	for _, task := range tasks {
+		outcome := ReviewOutcome{PullRequestID: task.PrID, ReviewerID: userID, Outcome: OutcomeNoCandidate}
+		if to, ok := newReviewer[task.PrID]; ok {
+			outcome.Outcome = OutcomeReassigned
+			outcome.NewReviewerID = to
+			report.ReassignedCount++
+		} else {
+			report.KeptCount++
+		}
+		report.Reviews = append(report.Reviews, outcome)
+	}
+	return report, tx.Commit()
+}
+
+// handoffSkipReason tells why the user holds no review on the OPEN PR prID.
+func handoffSkipReason(ctx context.Context, q querier, prID string) (string, error) {
+	var status string
+	err := q.QueryRowContext(ctx, "SELECT status FROM pull_requests WHERE id = $1", prID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return HandoffNotFound, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if status != "OPEN" {
+		return HandoffNotOpen, nil
+	}
+	return HandoffNotAssigned, nil
+}
@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"pr-reviewer/internal/store"
+)
+
+// ArchiveWorker periodically archives merged and closed PRs older than the
+// retention period.
+type ArchiveWorker struct {
+	store     *store.Store
+	retention time.Duration
+	interval  time.Duration
+}
+
+func NewArchiveWorker(st *store.Store, retention, interval time.Duration) *ArchiveWorker {
+	return &ArchiveWorker{store: st, retention: retention, interval: interval}
+}
+
+func (w *ArchiveWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			result, err := w.store.ArchivePullRequests(ctx, time.Now().Add(-w.retention))
+			if err != nil {
+				log.Printf("Archive worker: archiving failed: %v", err)
+				continue
+			}
+			if result.ArchivedPRs > 0 {
+				log.Printf("Archive worker: archived %d PRs and %d reviews", result.ArchivedPRs, result.ArchivedReviews)
+			}
+		}
+	}
+}
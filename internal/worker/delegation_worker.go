@@ -0,0 +1,42 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"pr-reviewer/internal/store"
+)
+
+// DelegationWorker periodically hands the open reviews of users whose
+// delegation has just started to their delegates.
+type DelegationWorker struct {
+	store    *store.Store
+	interval time.Duration
+}
+
+func NewDelegationWorker(st *store.Store, interval time.Duration) *DelegationWorker {
+	return &DelegationWorker{store: st, interval: interval}
+}
+
+func (w *DelegationWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			transfers, err := w.store.StartDueDelegations(ctx)
+			if err != nil {
+				log.Printf("Delegation worker: transfer failed: %v", err)
+				continue
+			}
+			for _, t := range transfers {
+				log.Printf("Delegation worker: delegation %s moved %d review(s), kept %d",
+					t.DelegationID, len(t.Moves), len(t.KeptReviews))
+			}
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"pr-reviewer/internal/model"
+	"pr-reviewer/internal/notify"
+	"pr-reviewer/internal/store"
+)
+
+// jobChunkSize is the number of users handled per transaction. Progress is
+// saved in the chunk's transaction and cancellation checked after every
+// chunk.
+const jobChunkSize = 25
+
+// JobWorker runs queued bulk jobs one at a time. Each chunk of a job commits
+// on its own, so a job interrupted by a restart resumes after its last saved
+// chunk.
+type JobWorker struct {
+	store    *store.Store
+	notifier notify.Notifier
+	interval time.Duration
+}
+
+func NewJobWorker(st *store.Store, notifier notify.Notifier, interval time.Duration) *JobWorker {
+	return &JobWorker{store: st, notifier: notifier, interval: interval}
+}
+
+func (w *JobWorker) Run(ctx context.Context) {
+	if n, err := w.store.RequeueRunningJobs(ctx); err != nil {
+		log.Printf("Job worker: requeueing interrupted jobs failed: %v", err)
+	} else if n > 0 {
+		log.Printf("Job worker: resuming %d interrupted job(s)", n)
+	}
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.RunPending(ctx)
+		}
+	}
+}
+
+// RunPending processes queued jobs until none is left or ctx is done.
+func (w *JobWorker) RunPending(ctx context.Context) {
+	for ctx.Err() == nil {
+		job, err := w.store.ClaimJob(ctx)
+		if err != nil {
+			log.Printf("Job worker: claiming a job failed: %v", err)
+			return
+		}
+		if job == nil {
+			return
+		}
+		w.process(ctx, job)
+	}
+}
+
+func (w *JobWorker) process(ctx context.Context, job *model.Job) {
+	switch job.Type {
+	case store.JobBulkDeactivate:
+		var payload store.BulkDeactivatePayload
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			w.finish(ctx, job, store.JobFailed, nil, err)
+			return
+		}
+		report := &store.DeactivationReport{}
+		if !w.resume(ctx, job, report) {
+			return
+		}
+		w.runChunks(ctx, job, payload.UserIDs, report, func(ids []string, processed int) (bool, error) {
+			chunk, cancel, err := w.store.RunDeactivationChunk(ctx, job.JobID, ids, payload.UserIDs, processed, report)
+			if err != nil {
+				return false, err
+			}
+			notify.AlertLeads(ctx, w.notifier, chunk.LeadAlerts)
+			return cancel, nil
+		})
+
+	case store.JobBulkReactivate:
+		var payload store.BulkReactivatePayload
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			w.finish(ctx, job, store.JobFailed, nil, err)
+			return
+		}
+		result := &store.BulkReactivateResult{Users: []store.ReactivationReport{}}
+		if !w.resume(ctx, job, result) {
+			return
+		}
+		w.runChunks(ctx, job, payload.UserIDs, result, func(ids []string, processed int) (bool, error) {
+			return w.store.RunReactivationChunk(ctx, job.JobID, ids, payload.Rebalance, processed, result)
+		})
+
+	default:
+		w.finish(ctx, job, store.JobFailed, nil, fmt.Errorf("unknown job type %q", job.Type))
+	}
+}
+
+// resume loads the result saved by an interrupted run into result.
+func (w *JobWorker) resume(ctx context.Context, job *model.Job, result any) bool {
+	if job.Result == nil {
+		return true
+	}
+	if err := json.Unmarshal(job.Result, result); err != nil {
+		w.finish(ctx, job, store.JobFailed, nil, fmt.Errorf("reading saved progress: %w", err))
+		return false
+	}
+	return true
+}
+
+// runChunks calls step for every chunk of ids after the ones already
+// processed. step applies the chunk and saves the progress, processed items
+// in all, in one transaction and reports whether the job has been asked to
+// stop. A failed chunk leaves nothing behind, so the job fails with the
+// result saved last. On shutdown the job is left RUNNING and picked up again
+// after the restart.
+func (w *JobWorker) runChunks(ctx context.Context, job *model.Job, ids []string, result any, step func(ids []string, processed int) (bool, error)) {
+	if job.CancelRequested {
+		w.finish(ctx, job, store.JobCancelled, result, nil)
+		return
+	}
+	for done := job.Processed; done < len(ids); {
+		end := min(done+jobChunkSize, len(ids))
+		cancel, err := step(ids[done:end], end)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			w.finish(ctx, job, store.JobFailed, nil, err)
+			return
+		}
+		done = end
+
+		if cancel {
+			w.finish(ctx, job, store.JobCancelled, result, nil)
+			return
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+	w.finish(ctx, job, store.JobSucceeded, result, nil)
+}
+
+func (w *JobWorker) finish(ctx context.Context, job *model.Job, status string, result any, jobErr error) {
+	msg := ""
+	if jobErr != nil {
+		msg = jobErr.Error()
+		log.Printf("Job worker: job %s failed: %v", job.JobID, jobErr)
+	}
+	if err := w.store.FinishJob(ctx, job.JobID, status, result, msg); err != nil {
+		log.Printf("Job worker: finishing job %s failed: %v", job.JobID, err)
+	}
+}
@@ -0,0 +1,47 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"pr-reviewer/internal/notify"
+	"pr-reviewer/internal/store"
+)
+
+// OffboardingWorker periodically deactivates users whose scheduled
+// deactivation date has passed.
+type OffboardingWorker struct {
+	store    *store.Store
+	notifier notify.Notifier
+	interval time.Duration
+}
+
+func NewOffboardingWorker(st *store.Store, notifier notify.Notifier, interval time.Duration) *OffboardingWorker {
+	return &OffboardingWorker{store: st, notifier: notifier, interval: interval}
+}
+
+func (w *OffboardingWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := w.store.RunDueDeactivations(ctx, time.Now())
+			if err != nil {
+				log.Printf("Offboarding worker: deactivation failed: %v", err)
+				continue
+			}
+			if report == nil {
+				continue
+			}
+			notify.AlertLeads(ctx, w.notifier, report.LeadAlerts)
+			for _, u := range report.Users {
+				log.Printf("Offboarding worker: %s %s (%d review(s) handled)", u.UserID, u.Status, len(u.Reviews))
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"pr-reviewer/internal/notify"
+	"pr-reviewer/internal/store"
+)
+
+// ReminderWorker periodically sends reminders about pending reviews according
+// to each team's reminder cadence.
+type ReminderWorker struct {
+	store    *store.Store
+	notifier notify.Notifier
+	interval time.Duration
+}
+
+func NewReminderWorker(st *store.Store, notifier notify.Notifier, interval time.Duration) *ReminderWorker {
+	return &ReminderWorker{store: st, notifier: notifier, interval: interval}
+}
+
+func (w *ReminderWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			now := time.Now()
+			reminders, teams, err := w.store.CollectDueReminders(ctx, now)
+			if err != nil {
+				log.Printf("Reminder worker: collecting reminders failed: %v", err)
+				continue
+			}
+			// A team is only marked as reminded once all of its reminders
+			// went out; otherwise it is retried on the next tick.
+			failed := make(map[string]bool)
+			for _, rem := range reminders {
+				event := notify.Event{
+					Type:      notify.TypeReviewReminder,
+					TeamName:  rem.TeamName,
+					UserID:    rem.ReviewerID,
+					Message:   fmt.Sprintf("%s, you have %d pending review(s)", rem.Username, len(rem.PullRequests)),
+					Data:      rem.PullRequests,
+					CreatedAt: now,
+				}
+				if err := w.notifier.Notify(ctx, event); err != nil {
+					log.Printf("Reminder worker: notifying %s failed: %v", rem.ReviewerID, err)
+					failed[rem.TeamName] = true
+				}
+			}
+			var delivered []string
+			for _, team := range teams {
+				if !failed[team] {
+					delivered = append(delivered, team)
+				}
+			}
+			if err := w.store.MarkReminded(ctx, delivered, now); err != nil {
+				log.Printf("Reminder worker: marking teams as reminded failed: %v", err)
+			}
+		}
+	}
+}
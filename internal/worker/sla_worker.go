@@ -0,0 +1,41 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"pr-reviewer/internal/store"
+)
+
+// SLAWorker periodically escalates reviews that missed their team's deadline.
+type SLAWorker struct {
+	store    *store.Store
+	interval time.Duration
+}
+
+func NewSLAWorker(st *store.Store, interval time.Duration) *SLAWorker {
+	return &SLAWorker{store: st, interval: interval}
+}
+
+func (w *SLAWorker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			escalations, err := w.store.EscalateOverdueReviews(ctx, time.Now())
+			if err != nil {
+				log.Printf("SLA worker: escalation failed: %v", err)
+				continue
+			}
+			for _, e := range escalations {
+				log.Printf("SLA worker: %s on %s (reviewer %s, new reviewer %q)",
+					e.Action, e.PullRequestID, e.ReviewerID, e.NewReviewerID)
+			}
+		}
+	}
+}
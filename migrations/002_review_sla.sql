@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS team_policies (
+    team_name VARCHAR(255) PRIMARY KEY REFERENCES teams(name),
+    review_sla_hours INTEGER CHECK (review_sla_hours > 0), -- NULL means no SLA
+    sla_action VARCHAR(50) NOT NULL DEFAULT 'REASSIGN', -- REASSIGN, ADD_BACKUP
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC' -- IANA name; working hours are counted in it
+);
+
+ALTER TABLE reviewers ADD COLUMN IF NOT EXISTS assigned_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE reviewers ADD COLUMN IF NOT EXISTS due_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE reviewers ADD COLUMN IF NOT EXISTS escalated_at TIMESTAMP WITH TIME ZONE;
+-- A backup reviewer added by the ADD_BACKUP escalation records whom they back
+-- up, so that every original reviewer gets at most one backup.
+ALTER TABLE reviewers ADD COLUMN IF NOT EXISTS backup_for VARCHAR(255);
+
+-- Index for the SLA worker scanning pending deadlines
+CREATE INDEX IF NOT EXISTS idx_reviewers_due_at ON reviewers(due_at) WHERE escalated_at IS NULL;
@@ -0,0 +1,3 @@
+ALTER TABLE team_policies ADD COLUMN IF NOT EXISTS reminder_interval_minutes INTEGER CHECK (reminder_interval_minutes > 0); -- NULL means no reminders
+ALTER TABLE team_policies ADD COLUMN IF NOT EXISTS reminder_threshold_hours INTEGER NOT NULL DEFAULT 24 CHECK (reminder_threshold_hours >= 0);
+ALTER TABLE team_policies ADD COLUMN IF NOT EXISTS last_reminder_at TIMESTAMP WITH TIME ZONE;
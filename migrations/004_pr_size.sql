@@ -0,0 +1,15 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_senior BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS lines_added INTEGER CHECK (lines_added >= 0);
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS lines_removed INTEGER CHECK (lines_removed >= 0);
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS changed_files INTEGER CHECK (changed_files >= 0);
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS size VARCHAR(2); -- XS, S, M, L, XL; NULL when unknown
+
+-- Number of reviewers per PR size bucket. Sizes without a rule get 2 reviewers.
+CREATE TABLE IF NOT EXISTS team_size_rules (
+    team_name VARCHAR(255) REFERENCES teams(name),
+    size VARCHAR(2) NOT NULL,
+    reviewer_count INTEGER NOT NULL CHECK (reviewer_count >= 0),
+    senior_required BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (team_name, size)
+);
@@ -0,0 +1,4 @@
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS parent_id VARCHAR(255) REFERENCES pull_requests(id);
+
+-- Index for finding the PRs stacked on top of a parent
+CREATE INDEX IF NOT EXISTS idx_pull_requests_parent_id ON pull_requests(parent_id);
@@ -0,0 +1,25 @@
+-- Merged and closed PRs past the retention period are moved here. The archive
+-- has no foreign keys so that it never blocks changes to live data.
+CREATE TABLE IF NOT EXISTS pull_requests_archive (
+    id VARCHAR(255) PRIMARY KEY,
+    name TEXT NOT NULL,
+    author_id VARCHAR(255) NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE,
+    merged_at TIMESTAMP WITH TIME ZONE,
+    lines_added INTEGER,
+    lines_removed INTEGER,
+    changed_files INTEGER,
+    size VARCHAR(2),
+    parent_id VARCHAR(255),
+    archived_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS reviewers_archive (
+    pull_request_id VARCHAR(255) NOT NULL,
+    user_id VARCHAR(255) NOT NULL,
+    assigned_at TIMESTAMP WITH TIME ZONE,
+    due_at TIMESTAMP WITH TIME ZONE,
+    escalated_at TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (pull_request_id, user_id)
+);
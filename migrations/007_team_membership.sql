@@ -0,0 +1,2 @@
+-- Users removed from their team keep their history but belong to no team.
+ALTER TABLE users ALTER COLUMN team_name DROP NOT NULL;
@@ -0,0 +1,2 @@
+-- Archived teams keep their name and history but have no members.
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP WITH TIME ZONE;
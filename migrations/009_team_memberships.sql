@@ -0,0 +1,25 @@
+-- Users can belong to several teams. users.team_name remains the user's
+-- primary team; eligibility for reviews in a team needs both the user and the
+-- membership to be active.
+CREATE TABLE IF NOT EXISTS team_memberships (
+    team_name VARCHAR(255) NOT NULL REFERENCES teams(name),
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id),
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (team_name, user_id)
+);
+
+INSERT INTO team_memberships (team_name, user_id)
+SELECT team_name, id FROM users WHERE team_name IS NOT NULL
+ON CONFLICT DO NOTHING;
+
+-- Index for listing the teams of a user
+CREATE INDEX IF NOT EXISTS idx_team_memberships_user_id ON team_memberships(user_id);
+
+-- The team whose members review a PR. Defaults to the author's primary team.
+-- Not a foreign key so that merged PRs keep their team after it is deleted.
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS team_name VARCHAR(255);
+UPDATE pull_requests p SET team_name = u.team_name FROM users u WHERE p.author_id = u.id AND p.team_name IS NULL;
+CREATE INDEX IF NOT EXISTS idx_pull_requests_team_status ON pull_requests(team_name, status);
+
+ALTER TABLE pull_requests_archive ADD COLUMN IF NOT EXISTS team_name VARCHAR(255);
@@ -0,0 +1,5 @@
+-- A team may belong to a parent team (department, tribe). Candidate searches
+-- fall back to the ancestors when the team itself is short of reviewers.
+ALTER TABLE teams ADD COLUMN IF NOT EXISTS parent_team VARCHAR(255) REFERENCES teams(name);
+
+CREATE INDEX IF NOT EXISTS idx_teams_parent_team ON teams(parent_team);
@@ -0,0 +1,9 @@
+-- Team leads are escalation contacts. They need not be members of the team.
+CREATE TABLE IF NOT EXISTS team_leads (
+    team_name VARCHAR(255) NOT NULL REFERENCES teams(name),
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id),
+    PRIMARY KEY (team_name, user_id)
+);
+
+ALTER TABLE team_policies ADD COLUMN IF NOT EXISTS no_candidate_action VARCHAR(50) NOT NULL DEFAULT 'NOTIFY'; -- NOTIFY, ASSIGN_LEAD
+ALTER TABLE team_policies ADD COLUMN IF NOT EXISTS leads_only_management BOOLEAN NOT NULL DEFAULT FALSE;
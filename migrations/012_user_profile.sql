@@ -0,0 +1,8 @@
+-- Profile fields and soft deletion. Deleted users keep their rows so that
+-- historical PRs and reviews still resolve.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email VARCHAR(255);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS handle VARCHAR(255);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users(email) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_handle ON users(handle) WHERE deleted_at IS NULL;
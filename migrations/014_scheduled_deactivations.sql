@@ -0,0 +1,15 @@
+-- Offboarding dates. A user with a PENDING entry gets no new reviews; once
+-- deactivate_at has passed a background worker deactivates them and
+-- reassigns their open reviews.
+CREATE TABLE IF NOT EXISTS scheduled_deactivations (
+    id VARCHAR(36) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id),
+    deactivate_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING', -- PENDING, DONE, CANCELLED
+    result JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    finished_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_scheduled_deactivations_pending ON scheduled_deactivations(user_id) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_scheduled_deactivations_due ON scheduled_deactivations(status, deactivate_at);
@@ -0,0 +1,19 @@
+-- A user's reviews go to a named colleague between starts_at and ends_at.
+-- With transfer_reviews the user's open reviews move to the delegate when the
+-- window starts; transferred_at records that this has happened.
+CREATE TABLE IF NOT EXISTS review_delegations (
+    id VARCHAR(36) PRIMARY KEY DEFAULT gen_random_uuid()::text,
+    user_id VARCHAR(255) NOT NULL REFERENCES users(id),
+    delegate_id VARCHAR(255) NOT NULL REFERENCES users(id),
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    transfer_reviews BOOLEAN NOT NULL DEFAULT false,
+    transferred_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    cancelled_at TIMESTAMP WITH TIME ZONE,
+    CHECK (user_id <> delegate_id),
+    CHECK (ends_at > starts_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_review_delegations_user ON review_delegations(user_id, ends_at);
+CREATE INDEX IF NOT EXISTS idx_review_delegations_delegate ON review_delegations(delegate_id, ends_at);
@@ -0,0 +1,7 @@
+-- When a PR was closed, so that retention counts from the close like it does
+-- from the merge. PRs closed before this column existed count as closed now,
+-- which delays their archival rather than archiving them early.
+ALTER TABLE pull_requests ADD COLUMN IF NOT EXISTS closed_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE pull_requests_archive ADD COLUMN IF NOT EXISTS closed_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE pull_requests SET closed_at = CURRENT_TIMESTAMP WHERE status = 'CLOSED' AND closed_at IS NULL;
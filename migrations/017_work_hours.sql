@@ -0,0 +1,4 @@
+-- Working hours of a team's weekdays as whole hours in its timezone; review
+-- SLAs only count time between the start and the end.
+ALTER TABLE team_policies ADD COLUMN IF NOT EXISTS work_day_start SMALLINT NOT NULL DEFAULT 9;
+ALTER TABLE team_policies ADD COLUMN IF NOT EXISTS work_day_end SMALLINT NOT NULL DEFAULT 17;
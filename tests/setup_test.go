@@ -15,7 +15,7 @@ func SetupTestDB(t *testing.T) *store.Store {
 		t.Fatalf("Failed to connect to DB: %v", err)
 	}
 
-	tables := []string{"reviewers", "pull_requests", "users", "teams"}
+	tables := []string{"review_delegations", "scheduled_deactivations", "jobs", "reviewers_archive", "pull_requests_archive", "reviewers", "pull_requests", "team_size_rules", "team_policies", "team_leads", "team_memberships", "users", "teams"}
 	for _, table := range tables {
 		_, err := db.Exec("TRUNCATE TABLE " + table + " CASCADE")
 		if err != nil {
@@ -0,0 +1,178 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"pr-reviewer/internal/model"
+	"pr-reviewer/internal/store"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	s := SetupTestDB(t)
+	ctx := context.Background()
+
+	s.CreateTeam(ctx, &model.Team{
+		TeamName: "platform",
+		Members:  []model.TeamMember{{UserID: "boss", Username: "Boss", IsActive: true}},
+	})
+	s.CreateTeam(ctx, &model.Team{
+		TeamName:   "backend",
+		ParentTeam: "platform",
+		Members: []model.TeamMember{
+			{UserID: "author", Username: "Author", IsActive: true},
+			{UserID: "r1", Username: "Rev1", IsActive: true, IsSenior: true},
+			{UserID: "r2", Username: "Rev2", IsActive: false},
+		},
+	})
+	if _, err := s.SetTeamLeads(ctx, "backend", []string{"boss"}); err != nil {
+		t.Fatalf("SetTeamLeads failed: %v", err)
+	}
+	sla := 8
+	err := s.SetTeamPolicy(ctx, &model.TeamPolicy{
+		TeamName:       "backend",
+		ReviewSLAHours: &sla,
+		SizeRules:      []model.SizeRule{{Size: "XL", ReviewerCount: 1, SeniorRequired: true}},
+	})
+	if err != nil {
+		t.Fatalf("SetTeamPolicy failed: %v", err)
+	}
+	s.CreatePullRequest(ctx, &model.PullRequest{ID: "pr-1", Name: "Base", AuthorID: "author"})
+	s.CreatePullRequest(ctx, &model.PullRequest{ID: "pr-2", Name: "Stacked", AuthorID: "author", DependsOn: "pr-1"})
+	s.MergePullRequest(ctx, "pr-1")
+	s.CreatePullRequest(ctx, &model.PullRequest{ID: "pr-3", Name: "Old", AuthorID: "author"})
+	s.MergePullRequest(ctx, "pr-3")
+	// pr-1 stays live as the parent of the open pr-2.
+	if _, err := s.ArchivePullRequests(ctx, time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("ArchivePullRequests failed: %v", err)
+	}
+	if _, err := s.ScheduleDeactivation(ctx, "r1", time.Now().Add(48*time.Hour)); err != nil {
+		t.Fatalf("ScheduleDeactivation failed: %v", err)
+	}
+	_, err = s.CreateDelegation(ctx, "boss", "author", time.Now().Add(24*time.Hour), time.Now().Add(72*time.Hour), true)
+	if err != nil {
+		t.Fatalf("CreateDelegation failed: %v", err)
+	}
+
+	exported, err := s.ExportSnapshot(ctx)
+	if err != nil {
+		t.Fatalf("ExportSnapshot failed: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := exported.WriteNDJSON(&buf); err != nil {
+		t.Fatalf("WriteNDJSON failed: %v", err)
+	}
+	snap, err := store.ReadSnapshotNDJSON(&buf)
+	if err != nil {
+		t.Fatalf("ReadSnapshotNDJSON failed: %v", err)
+	}
+
+	if _, err := s.ImportSnapshot(ctx, snap); !errors.Is(err, store.ErrNotEmpty) {
+		t.Errorf("Expected ErrNotEmpty, got %v", err)
+	}
+
+	s = SetupTestDB(t)
+	result, err := s.ImportSnapshot(ctx, snap)
+	if err != nil {
+		t.Fatalf("ImportSnapshot failed: %v", err)
+	}
+	if result.Teams != 2 || result.Users != 4 || result.PullRequests != 2 || result.ArchivedPullRequests != 1 || result.ArchivedReviews != 2 ||
+		result.Schedules != 1 || result.Delegations != 1 {
+		t.Errorf("Unexpected import counts: %+v", result)
+	}
+
+	team, err := s.GetTeam(ctx, "backend")
+	if err != nil {
+		t.Fatalf("GetTeam failed: %v", err)
+	}
+	if team.ParentTeam != "platform" || len(team.Leads) != 1 || len(team.Members) != 3 {
+		t.Errorf("Team not restored: %+v", team)
+	}
+	policy, _ := s.GetTeamPolicy(ctx, "backend")
+	if policy.ReviewSLAHours == nil || *policy.ReviewSLAHours != 8 || len(policy.SizeRules) != 1 {
+		t.Errorf("Policy not restored: %+v", policy)
+	}
+	pr, err := s.GetPullRequest(ctx, "pr-2")
+	if err != nil {
+		t.Fatalf("GetPullRequest failed: %v", err)
+	}
+	// r1 from backend and boss from the parent team.
+	if pr.DependsOn != "pr-1" || len(pr.AssignedReviewers) != 2 {
+		t.Errorf("PR not restored: %+v", pr)
+	}
+	merged, _ := s.GetPullRequest(ctx, "pr-1")
+	if merged.Status != "MERGED" || merged.MergedAt == nil {
+		t.Errorf("Merged PR not restored: %+v", merged)
+	}
+	schedules, _ := s.ListScheduledDeactivations(ctx, store.SchedulePending, "r1")
+	if len(schedules) != 1 || schedules[0].ScheduleID != exported.ScheduledDeactivations[0].ID {
+		t.Errorf("Scheduled deactivation not restored: %+v", schedules)
+	}
+	delegations, _ := s.ListDelegations(ctx, "boss", false)
+	if len(delegations) != 1 || delegations[0].Status != store.DelegationScheduled || !delegations[0].TransferReviews {
+		t.Errorf("Delegation not restored: %+v", delegations)
+	}
+	// The archived id stays reserved after the restore.
+	err = s.CreatePullRequest(ctx, &model.PullRequest{ID: "pr-3", Name: "Again", AuthorID: "author"})
+	if !errors.Is(err, store.ErrPRExists) {
+		t.Errorf("Expected ErrPRExists for an archived id, got %v", err)
+	}
+}
+
+func TestSnapshotValidation(t *testing.T) {
+	s := SetupTestDB(t)
+	ctx := context.Background()
+
+	snap := &store.Snapshot{
+		Version: store.SnapshotVersion,
+		Teams:   []store.SnapshotTeam{{Name: "a", ParentTeam: "b"}, {Name: "b", ParentTeam: "a"}},
+		Users: []store.SnapshotUser{
+			{ID: "u1", Username: "One", PrimaryTeam: "c"},
+		},
+		PullRequests: []store.SnapshotPullRequest{
+			{ID: "pr-1", Name: "Fix", AuthorID: "ghost", Status: "OPEN"},
+			{ID: "pr-2", Name: "Left", AuthorID: "u1", Status: "OPEN", DependsOn: "pr-3", TeamName: "nowhere"},
+			{ID: "pr-3", Name: "Right", AuthorID: "u1", Status: "OPEN", DependsOn: "pr-2"},
+		},
+		ArchivedPullRequests: []store.SnapshotPullRequest{
+			{ID: "pr-1", Name: "Fix", AuthorID: "ghost", Status: "OPEN"},
+		},
+	}
+	_, err := s.ImportSnapshot(ctx, snap)
+	if !errors.Is(err, store.ErrInvalidSnapshot) {
+		t.Fatalf("Expected ErrInvalidSnapshot, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `pull request "pr-1" is both live and archived`) {
+		t.Errorf("Expected the live and archived pr-1 to clash, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `the dependencies of pull request "pr-2" form a cycle`) {
+		t.Errorf("Expected the depends_on cycle to be rejected, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `pull request "pr-2" has unknown team "nowhere"`) {
+		t.Errorf("Expected the unknown target team to be rejected, got %v", err)
+	}
+
+	snap.Version = 99
+	if _, err := s.ImportSnapshot(ctx, snap); !errors.Is(err, store.ErrInvalidSnapshot) {
+		t.Errorf("Expected ErrInvalidSnapshot for an unknown version, got %v", err)
+	}
+
+	exported, err := s.ExportSnapshot(ctx)
+	if err != nil {
+		t.Fatalf("ExportSnapshot failed: %v", err)
+	}
+	if count := len(exported.Teams) + len(exported.Users) + len(exported.PullRequests); count != 0 {
+		t.Errorf("A rejected import must not write anything, found %d records", count)
+	}
+
+	// Version 1 snapshots predate scheduled deactivations and delegations
+	// and still import.
+	old := &store.Snapshot{Version: 1, Teams: []store.SnapshotTeam{{Name: "solo"}}}
+	if _, err := s.ImportSnapshot(ctx, old); err != nil {
+		t.Errorf("Expected a version 1 snapshot to import, got %v", err)
+	}
+}